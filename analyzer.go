@@ -2,6 +2,9 @@ package jsluice
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"strings"
 	"unicode"
 
 	"github.com/PuerkitoBio/goquery"
@@ -13,33 +16,281 @@ import (
 // the parse tree for a JavaScript file and provides mechanisms to
 // extract URLs, secrets etc
 type Analyzer struct {
+	// IncludeComments enables searching comments for URLs in GetURLs
+	IncludeComments bool
+
+	// IncludeStyles enables searching CSS found alongside the JavaScript
+	// (e.g. in <style> tags and style attributes) for url(...) and @import
+	// URLs in GetURLs. It is enabled by default when the source is CSS.
+	IncludeStyles bool
+
+	// ExpressionPlaceholder is used in place of expressions when
+	// string concatenations are collapsed. It defaults to the value
+	// of the global ExpressionPlaceholder when the Analyzer is created.
+	ExpressionPlaceholder string
+
+	// DecodeHTMLEntities enables decoding of HTML entities (e.g. &amp;)
+	// in URLs found by GetURLs. It is enabled by default when the source
+	// is HTML, because inline scripts are often HTML-encoded.
+	DecodeHTMLEntities bool
+
+	// Debug, if set, receives a log of the decisions made by URL
+	// matchers in GetURLs, including why candidates were rejected
+	Debug io.Writer
+
+	// Beautify enables running jsbeautifier-go on the source before it
+	// is analyzed. Minified code can defeat some of the heuristics used
+	// by matchers, so this can improve accuracy, but at a CPU cost. It
+	// takes effect the first time the source is queried.
+	Beautify bool
+
+	// Deobfuscate enables replacing obfuscated expressions that evaluate
+	// to strings (e.g. JSFuck, or String.fromCharCode(104, 116, ...)) with
+	// string literals before the source is analyzed. Evaluation is strictly
+	// limited so that it can't hang. It takes effect the first time the
+	// source is queried.
+	Deobfuscate bool
+
+	// MaxURLStringLength is the length (in bytes) above which string literals
+	// aren't considered by the stringLiteral URL matcher. Minified files can
+	// contain enormous strings that are slow to check and are very unlikely
+	// to be URLs. It defaults to DefaultMaxURLStringLength; zero means there
+	// is no limit.
+	MaxURLStringLength int
+
+	// MatchTrailingStrings enables matching assignments to location etc
+	// where the value is a concatenation that only ends in a string; e.g.
+	// location.href = base + "/login", which is found as EXPR/login. The
+	// start of the URL is unknown, so it's off by default.
+	MatchTrailingStrings bool
+
+	// MaxResults, if greater than zero, is the maximum number of results
+	// that GetURLs and GetSecrets will return. It's a safety valve for
+	// untrusted input that could otherwise produce millions of results.
+	MaxResults int
+
 	urlMatchers        []URLMatcher
 	rootNode           *Node
 	userSecretMatchers []SecretMatcher
+
+	// the tree for the source as it was given, which Reparse edits.
+	// It's nil once the source has been beautified or deobfuscated.
+	tree *sitter.Tree
+
+	// how the source was interpreted, which Reparse uses for the new source
+	hint SourceHint
+
+	// the grammar that the source is parsed with, and
+	// that queries against the parse tree are compiled for
+	lang *sitter.Language
+
+	// any CSS found alongside the JavaScript, e.g. in <style> tags
+	css []byte
+
+	// reasons recorded for the current matcher's decision when Debug is set
+	debugReasons []string
+
+	// constants shared between the files analyzed by a MultiAnalyzer
+	symbols map[string]string
+
+	// set once the source has been beautified
+	beautified bool
+
+	// set once the source has been deobfuscated
+	deobfuscated bool
+
+	// set when results were dropped because of MaxResults
+	truncated bool
 }
 
+// A SourceHint tells NewAnalyzerWithHint how the source it has
+// been given should be interpreted
+type SourceHint int
+
+const (
+	// AutoDetect inspects the source to decide if it is JavaScript,
+	// HTML, or a single-file component
+	AutoDetect SourceHint = iota
+
+	// ForceJS treats the source as JavaScript, even if it looks like HTML
+	ForceJS
+
+	// ForceHTML treats the source as HTML and extracts any inline JavaScript
+	ForceHTML
+
+	// ForceVue treats the source as a Vue single-file component and
+	// extracts the contents of any <script> and <style> blocks
+	ForceVue
+
+	// ForceSvelte treats the source as a Svelte component and
+	// extracts the contents of any <script> and <style> blocks
+	ForceSvelte
+
+	// ForceCSS treats the source as CSS, so that only URLs
+	// referenced with url(...) or @import are extracted
+	ForceCSS
+
+	// ForceJSON treats the source as a JSON document (e.g. an API
+	// response), so that secrets etc in its objects are found
+	ForceJSON
+)
+
 // NewAnalyzer accepts a slice of bytes representing some JavaScript
 // source code and returns a pointer to a new Analyzer
 func NewAnalyzer(source []byte) *Analyzer {
-	parser := sitter.NewParser()
+	return NewAnalyzerWithHint(source, AutoDetect)
+}
 
-	parser.SetLanguage(javascript.GetLanguage())
+// DefaultMaxURLStringLength is the default for Analyzer.MaxURLStringLength
+const DefaultMaxURLStringLength = 4096
 
-	if isProbablyHTML(source) {
-		source = extractInlineJS(source)
+// ErrEmptySource is returned by Analyze when the source is empty
+// or contains only whitespace
+var ErrEmptySource = errors.New("jsluice: source is empty")
+
+// ErrUnparseable is returned by Analyze when none of the source
+// could be parsed as JavaScript; e.g. because it's a binary file
+var ErrUnparseable = errors.New("jsluice: source could not be parsed as JavaScript")
+
+// Analyze is like NewAnalyzer, but returns an error for source that
+// there's no point analyzing, instead of an Analyzer that silently
+// finds nothing. Source that only partly parses (e.g. TypeScript) is
+// not an error; use HasErrors and ErrorNodes to find out about that.
+func Analyze(source []byte) (*Analyzer, error) {
+	if len(bytes.TrimSpace(source)) == 0 {
+		return nil, ErrEmptySource
+	}
+
+	a := NewAnalyzer(source)
+
+	root := a.rootNode
+	if root.node == nil {
+		return nil, ErrUnparseable
 	}
 
-	tree := parser.Parse(nil, source)
+	// HTML without any inline scripts etc leaves nothing to parse,
+	// but that's not a failure to parse the source
+	count := root.NamedChildCount()
+	if count == 0 {
+		return a, nil
+	}
+
+	for i := 0; i < count; i++ {
+		if root.NamedChild(i).Type() != "ERROR" {
+			return a, nil
+		}
+	}
+
+	return nil, ErrUnparseable
+}
+
+// NewAnalyzerWithHint is like NewAnalyzer, but the provided SourceHint
+// is used to decide how the source should be interpreted instead of
+// trying to detect it. This is useful when the type of the source is
+// already known; e.g. from a Content-Type header or a file extension.
+func NewAnalyzerWithHint(source []byte, hint SourceHint) *Analyzer {
+	if hint == AutoDetect {
+		hint = detectSourceHint(source)
+	}
+
+	source, css := prepareSource(source, hint)
 
 	// TODO: Align how URLMatcher and SecretMatcher slices
 	// are loaded. At the moment we load URLMatchers now,
 	// and SecretMatchers only when GetSecrets is called.
 	// This is mostly because URL matching was written first,
 	// and then secret matching was added later.
-	return &Analyzer{
+	a := &Analyzer{
+		ExpressionPlaceholder: ExpressionPlaceholder,
+		DecodeHTMLEntities:    hint == ForceHTML,
+		IncludeStyles:         hint == ForceCSS,
+		MaxURLStringLength:    DefaultMaxURLStringLength,
+
 		urlMatchers: AllURLMatchers(),
-		rootNode:    NewNode(tree.RootNode(), source),
+		css:         css,
+		hint:        hint,
+		lang:        javascript.GetLanguage(),
 	}
+
+	a.tree = a.parse(nil, source)
+	a.rootNode = a.newRoot(a.tree, source)
+
+	return a
+}
+
+// Lang returns the tree-sitter grammar that the source is parsed with
+func (a *Analyzer) Lang() *sitter.Language {
+	return a.lang
+}
+
+// parse parses some source with the Analyzer's grammar. The old tree, if
+// there is one, is reused for the parts of the source that haven't changed.
+func (a *Analyzer) parse(oldTree *sitter.Tree, source []byte) *sitter.Tree {
+	parser := sitter.NewParser()
+	parser.SetLanguage(a.lang)
+
+	return parser.Parse(oldTree, source)
+}
+
+// newRoot returns the root Node for a tree that
+// was parsed from some source by the Analyzer
+func (a *Analyzer) newRoot(tree *sitter.Tree, source []byte) *Node {
+	root := NewNode(tree.RootNode(), source)
+	root.analyzer = a
+	root.lang = a.lang
+
+	return root
+}
+
+// prepareSource returns the JavaScript to parse for some source, and any
+// CSS that was found with it, according to how the source is interpreted
+func prepareSource(source []byte, hint SourceHint) ([]byte, []byte) {
+	switch hint {
+	case ForceHTML:
+		return extractInlineCode(source)
+	case ForceVue, ForceSvelte:
+		return extractComponentBlocks(source)
+	case ForceCSS:
+		return []byte{}, source
+	case ForceJSON:
+		// A bare JSON object would be parsed as a block statement,
+		// but wrapped in parens it's an object literal expression
+		wrapped := make([]byte, 0, len(source)+2)
+		wrapped = append(wrapped, '(')
+		wrapped = append(wrapped, source...)
+		return append(wrapped, ')'), nil
+	default:
+		return source, nil
+	}
+}
+
+// Formatted returns a new Analyzer for a beautified version of the
+// JavaScript being analyzed, so that queries etc can be run against
+// readable code. Options and any matchers that have been added are
+// carried over to the new Analyzer.
+func (a *Analyzer) Formatted() (*Analyzer, error) {
+	formatted, err := a.RootNode().Format()
+	if err != nil {
+		return nil, err
+	}
+
+	f := NewAnalyzerWithHint([]byte(formatted), ForceJS)
+
+	f.IncludeComments = a.IncludeComments
+	f.IncludeStyles = a.IncludeStyles
+	f.ExpressionPlaceholder = a.ExpressionPlaceholder
+	f.DecodeHTMLEntities = a.DecodeHTMLEntities
+	f.Debug = a.Debug
+	f.MaxResults = a.MaxResults
+	f.MaxURLStringLength = a.MaxURLStringLength
+	f.MatchTrailingStrings = a.MatchTrailingStrings
+
+	f.urlMatchers = append([]URLMatcher{}, a.urlMatchers...)
+	f.userSecretMatchers = append([]SecretMatcher{}, a.userSecretMatchers...)
+	f.css = a.css
+	f.symbols = a.symbols
+
+	return f, nil
 }
 
 // Query peforms a tree-sitter query on the JavaScript being analyzed.
@@ -47,7 +298,7 @@ func NewAnalyzer(source []byte) *Analyzer {
 // See https://tree-sitter.github.io/tree-sitter/using-parsers#query-syntax
 // for details on query syntax.
 func (a *Analyzer) Query(q string, fn func(*Node)) {
-	a.rootNode.Query(q, fn)
+	a.RootNode().Query(q, fn)
 }
 
 // Query peforms a tree-sitter query on the JavaScript being analyzed.
@@ -56,14 +307,67 @@ func (a *Analyzer) Query(q string, fn func(*Node)) {
 // See https://tree-sitter.github.io/tree-sitter/using-parsers#query-syntax
 // for details on query syntax.
 func (a *Analyzer) QueryMulti(q string, fn func(QueryResult)) {
-	a.rootNode.QueryMulti(q, fn)
+	a.RootNode().QueryMulti(q, fn)
+}
+
+// Truncated returns true if the results of the most recent call to
+// GetURLs or GetSecrets were cut short because of MaxResults
+func (a *Analyzer) Truncated() bool {
+	return a.truncated
+}
+
+// atLimit returns true if count results is as many as MaxResults
+// allows, and marks the results as truncated
+func (a *Analyzer) atLimit(count int) bool {
+	if a.MaxResults <= 0 || count < a.MaxResults {
+		return false
+	}
+	a.truncated = true
+	return true
 }
 
 // RootNode returns the root note of the parsed JavaScript
 func (a *Analyzer) RootNode() *Node {
+	if a.Beautify && !a.beautified {
+		a.beautify()
+	}
+	if a.Deobfuscate && !a.deobfuscated {
+		a.deobfuscate()
+	}
 	return a.rootNode
 }
 
+// beautify replaces the parse tree with one for a beautified version
+// of the source. The original tree is kept if beautifying fails.
+func (a *Analyzer) beautify() {
+	a.beautified = true
+
+	formatted, err := a.rootNode.Format()
+	if err != nil {
+		return
+	}
+
+	source := []byte(formatted)
+	a.rootNode = a.newRoot(a.parse(nil, source), source)
+	a.tree = nil
+}
+
+// detectSourceHint looks at the provided source and returns
+// the SourceHint that best describes it
+func detectSourceHint(source []byte) SourceHint {
+	if !isProbablyHTML(source) {
+		return ForceJS
+	}
+
+	// Vue and Svelte components are handled the same way,
+	// so it doesn't matter which one we say it is
+	if isProbablyComponent(source) {
+		return ForceVue
+	}
+
+	return ForceHTML
+}
+
 // isProbablyHTML returns true for source that is probably HTML.
 // False positives are OK as long as the false positives are not
 // JavaScript source.
@@ -82,12 +386,15 @@ func isProbablyHTML(source []byte) bool {
 	return false
 }
 
-// extractInlineJS extracts inline JavaScript from HTML pages using goquery.
-func extractInlineJS(source []byte) []byte {
+// extractInlineCode extracts inline JavaScript and CSS from HTML pages
+// using goquery. JavaScript is taken from both <script> tags and event
+// handler attributes (e.g. onclick), and CSS is taken from both <style>
+// tags and style attributes.
+func extractInlineCode(source []byte) ([]byte, []byte) {
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(source))
 	if err != nil {
 		// Not a valid HTML document, so just return the source.
-		return source
+		return source, nil
 	}
 
 	var inline []byte
@@ -96,8 +403,28 @@ func extractInlineJS(source []byte) []byte {
 			inline = append(inline, []byte(s.Text()+"\n")...)
 		}
 	})
+
+	// Event handlers are the body of a function, so they're wrapped in
+	// one; otherwise things like 'return false' wouldn't parse
+	doc.Find("*").Each(func(i int, s *goquery.Selection) {
+		for _, attr := range s.Nodes[0].Attr {
+			if !strings.HasPrefix(strings.ToLower(attr.Key), "on") || strings.TrimSpace(attr.Val) == "" {
+				continue
+			}
+			inline = append(inline, []byte("(function(event) {\n"+attr.Val+"\n});\n")...)
+		}
+	})
+
+	var css []byte
+	doc.Find("style").Each(func(i int, s *goquery.Selection) {
+		css = append(css, []byte(s.Text()+"\n")...)
+	})
+	doc.Find("[style]").Each(func(i int, s *goquery.Selection) {
+		css = append(css, []byte(s.AttrOr("style", "")+"\n")...)
+	})
+
 	if len(inline) == 0 {
-		return source
+		return source, css
 	}
-	return inline
+	return inline, css
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// applyConfig reads a YAML or JSON config file (YAML is a superset of JSON,
+// so both are parsed the same way) and uses it to set the default values of
+// flags. Keys are the long names of flags, without the leading dashes; e.g.
+//
+//	concurrency: 10
+//	exclude: ["*.min.js", "**/vendor/**"]
+//	severity-override:
+//	  stripeKey: medium
+//
+// Lists set a flag once for each value, and maps set it once for each
+// key=value pair. Flags that were given on the command line are left
+// alone, so they always override the config file.
+func applyConfig(flags *flag.FlagSet, filename string) error {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	config := make(map[string]any)
+	err = yaml.Unmarshal(raw, &config)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flags.Lookup(name)
+		if f == nil || name == "config" {
+			return fmt.Errorf("unknown flag %q", name)
+		}
+
+		if f.Changed {
+			continue
+		}
+
+		for _, value := range configValues(config[name]) {
+			err := flags.Set(name, value)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// configValues converts a value from a config file into the values to
+// set a flag with
+func configValues(v any) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := make([]string, 0, len(v))
+		for _, k := range keys {
+			out = append(out, fmt.Sprintf("%s=%v", k, v[k]))
+		}
+		return out
+
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
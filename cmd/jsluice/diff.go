@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// A diffEntry is a URL or secret that was added to, or removed from,
+// the output of jsluice between two scans
+type diffEntry struct {
+	Change string         `json:"change"`
+	Kind   string         `json:"kind"`
+	Result map[string]any `json:"result"`
+}
+
+// diffScans compares the output of two previous runs of the urls or
+// secrets modes, and writes out any results that were added or removed
+// between them, grouped by kind (url, or the kind of secret)
+func diffScans(opts options, oldFile, newFile string, w io.Writer) error {
+	before, err := readScan(oldFile)
+	if err != nil {
+		return err
+	}
+
+	after, err := readScan(newFile)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]diffEntry, 0)
+
+	for fp, result := range before {
+		if _, exists := after[fp]; !exists {
+			entries = append(entries, diffEntry{"removed", resultKind(result), result})
+		}
+	}
+
+	for fp, result := range after {
+		if _, exists := before[fp]; !exists {
+			entries = append(entries, diffEntry{"added", resultKind(result), result})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		if entries[i].Change != entries[j].Change {
+			// removed before added
+			return entries[i].Change > entries[j].Change
+		}
+		return fingerprint(entries[i].Result) < fingerprint(entries[j].Result)
+	})
+
+	// The selected fields apply to the results that
+	// changed rather than to the diff entries themselves
+	fields := opts.fields
+	opts.fields = nil
+
+	for _, e := range entries {
+		if len(fields) > 0 {
+			selected := make(map[string]any, len(fields))
+			for _, field := range fields {
+				if v, exists := e.Result[field]; exists {
+					selected[field] = v
+				}
+			}
+			e.Result = selected
+		}
+
+		j, err := marshal(opts, e)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", j)
+	}
+
+	return nil
+}
+
+// readScan reads a file containing the JSONL output of jsluice
+// and returns the results keyed by their fingerprints
+func readScan(filename string) (map[string]map[string]any, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out := make(map[string]map[string]any)
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+
+		var result map[string]any
+		err := json.Unmarshal([]byte(line), &result)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		out[fingerprint(result)] = result
+	}
+
+	return out, sc.Err()
+}
+
+// resultKind returns "url" for URLs, and the kind of secret for secrets
+func resultKind(result map[string]any) string {
+	if kind, ok := result["kind"].(string); ok {
+		return kind
+	}
+	return "url"
+}
+
+// fingerprint returns a string that identifies a result across scans.
+// Filenames are not included, because they often change between builds
+// (e.g. main.3f2a1b.js), and neither is the source code or anything else
+// that doesn't describe the result itself.
+func fingerprint(result map[string]any) string {
+	if _, isSecret := result["kind"]; isSecret {
+		// encoding/json sorts map keys, so the data is always
+		// marshalled the same way for the same values
+		data, _ := json.Marshal(result["data"])
+		return fmt.Sprintf("secret\x00%v\x00%s", result["kind"], data)
+	}
+
+	return fmt.Sprintf("url\x00%v\x00%v\x00%v", result["url"], result["method"], result["type"])
+}
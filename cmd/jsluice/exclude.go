@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// compileExcludes converts the globs given with --exclude into regular
+// expressions. As well as the usual *, ?, and [...] patterns, ** matches
+// any number of directories; e.g. **/vendor/** or static/**/*.min.js.
+// Globs that don't contain a slash are matched against the base name of
+// each file, so *.min.js excludes minified files in any directory.
+func compileExcludes(globs []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(globs))
+
+	for _, glob := range globs {
+		re, err := regexp.Compile(globToRegexp(glob))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, re)
+	}
+
+	return out, nil
+}
+
+func globToRegexp(glob string) string {
+	b := &strings.Builder{}
+	b.WriteString("^")
+
+	if !strings.Contains(glob, "/") {
+		b.WriteString("(.*/)?")
+	}
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				b.WriteString("(.*/)?")
+				i += 2
+				continue
+			}
+			if strings.HasPrefix(glob[i:], "**") {
+				b.WriteString(".*")
+				i++
+				continue
+			}
+			b.WriteString("[^/]*")
+
+		case '?':
+			b.WriteString("[^/]")
+
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String()
+}
+
+// excluded returns true if the file matches any of the --exclude globs
+func excluded(opts options, filename string) bool {
+	filename = excludePath(filename)
+
+	for _, re := range opts.excludes {
+		if re.MatchString(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludePath returns the form of a filename that the --exclude globs are
+// matched against. Paths are cleaned, so ./static/app.js matches static/*.
+// URLs are matched on their scheme, host, and path, without the query
+// string, so https://cdn.example.com/** matches any file on that host.
+func excludePath(filename string) string {
+	if strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://") {
+		u, err := url.Parse(filename)
+		if err != nil {
+			return filename
+		}
+
+		p := u.Path
+		if p == "" {
+			p = "/"
+		}
+		return u.Scheme + "://" + strings.ToLower(u.Host) + p
+	}
+
+	return path.Clean(strings.ReplaceAll(filename, `\`, "/"))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	cases := []struct {
+		glob     string
+		in       string
+		expected bool
+	}{
+		{"*.min.js", "app.min.js", true},
+		{"*.min.js", "static/js/app.min.js", true},
+		{"*.min.js", "app.js", false},
+		{"**/vendor/**", "vendor/lib.js", true},
+		{"**/vendor/**", "src/vendor/a/b.js", true},
+		{"**/vendor/**", "src/vendors/b.js", false},
+		{"static/**/*.js", "static/app.js", true},
+		{"static/**/*.js", "static/js/app.js", true},
+		{"test/fixtures/*", "test/fixtures/a.js", true},
+		{"test/fixtures/*", "test/fixtures/sub/a.js", false},
+		{"app?.js", "app1.js", true},
+		{"app?.js", "app12.js", false},
+		{"[!a]*.js", "b.js", true},
+		{"[!a]*.js", "a.js", false},
+		{"a+b.js", "a+b.js", true},
+		{"a+b.js", "aab.js", false},
+	}
+
+	for _, c := range cases {
+		re := regexp.MustCompile(globToRegexp(c.glob))
+		actual := re.MatchString(c.in)
+		if actual != c.expected {
+			t.Errorf("want %t for glob %s matching %s; have %t", c.expected, c.glob, c.in, actual)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	cases := []struct {
+		glob     string
+		in       string
+		expected bool
+	}{
+		{"static/*", "./static/app.js", true},
+		{"static/*", `static\app.js`, true},
+		{"*.min.js", "https://cdn.example.com/js/app.min.js?v=3", true},
+		{"https://cdn.example.com/**", "https://cdn.example.com/x.js", true},
+		{"https://cdn.example.com/**", "https://CDN.example.com/x.js?v=1#top", true},
+		{"https://cdn.example.com/**", "https://cdn.example.com", true},
+		{"https://cdn.example.com/**", "https://example.com/cdn.example.com/x.js", false},
+		{"http://*/vendor/**", "http://example.com/vendor/lib.js", true},
+		{"http://*/vendor/**", "https://example.com/vendor/lib.js", false},
+	}
+
+	for _, c := range cases {
+		excludes, err := compileExcludes([]string{c.glob})
+		if err != nil {
+			t.Fatalf("failed to compile %s: %s", c.glob, err)
+		}
+
+		actual := excluded(options{excludes: excludes}, c.in)
+		if actual != c.expected {
+			t.Errorf("want %t for --exclude %s on %s; have %t", c.expected, c.glob, c.in, actual)
+		}
+	}
+}
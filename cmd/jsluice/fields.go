@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// selectFields takes a JSON object and returns a new JSON object containing
+// only the provided fields, in the order they were provided. Fields that
+// don't exist are skipped. JSON that isn't an object is returned unchanged.
+func selectFields(j []byte, fields []string) ([]byte, error) {
+	var all map[string]json.RawMessage
+	err := json.Unmarshal(j, &all)
+	if err != nil {
+		return j, nil
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteByte('{')
+
+	first := true
+	for _, field := range fields {
+		value, exists := all[field]
+		if !exists {
+			continue
+		}
+
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+
+		key, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+
+		out.Write(key)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+
+	out.WriteByte('}')
+
+	return out.Bytes(), nil
+}
+
+// tagResult adds a result field to the start of a JSON object for a URL
+// or a secret, saying which of the two it is, so that they can be told
+// apart when they're output together. Secrets already have a kind field
+// (e.g. AWSAccessKey), so that name can't be used.
+func tagResult(j []byte, v any) []byte {
+	var result string
+	switch v.(type) {
+	case *jsluice.URL:
+		result = "url"
+	case *jsluice.Secret:
+		result = "secret"
+	default:
+		return j
+	}
+
+	if len(j) < 2 || j[0] != '{' {
+		return j
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString(`{"result":"` + result + `"`)
+	if j[1] != '}' {
+		out.WriteByte(',')
+	}
+	out.Write(j[1:])
+
+	return out.Bytes()
+}
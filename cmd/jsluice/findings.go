@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// extract outputs the URLs and/or the secrets in a file for the urls,
+// secrets, and all modes. When --only-with-urls or --only-with-secrets is
+// specified, nothing is output for files that don't have any of those,
+// even if that means looking for a kind of result that won't be output;
+// e.g. secrets in the urls mode.
+func extract(opts options, filename string, source []byte, withURLs, withSecrets bool, output chan string, errs chan error) {
+	analyzer := newAnalyzer(opts, filename, source)
+	defer warnTruncated(opts, filename, analyzer)
+
+	var urls []*jsluice.URL
+	if withURLs || opts.onlyWithURLs {
+		var err error
+		urls, err = findURLs(opts, filename, analyzer)
+		if err != nil {
+			errs <- err
+		}
+	}
+
+	var secrets []*jsluice.Secret
+	if withSecrets || opts.onlyWithSecrets {
+		secrets = findSecrets(opts, filename, analyzer)
+	}
+
+	// SARIF counts columns differently, and the columns
+	// of a result can only be converted using its source
+	if opts.sarif != nil {
+		for _, u := range urls {
+			u.Position = utf16Position(source, u.Position)
+		}
+		for _, s := range secrets {
+			s.Position = utf16Position(source, s.Position)
+		}
+	}
+
+	if opts.onlyWithURLs && len(urls) == 0 {
+		return
+	}
+
+	if opts.onlyWithSecrets && len(secrets) == 0 {
+		return
+	}
+
+	n := 0
+	if withURLs {
+		n += writeURLs(opts, urls, output, errs)
+	}
+	if withSecrets {
+		n += writeSecrets(opts, secrets, output)
+	}
+
+	if n > 0 {
+		opts.findings.add(filename)
+	}
+}
+
+// findingFiles collects the names of the files that any results were
+// output for, so that a list of them can be written once the scan is
+// done; e.g. as an index of where to look after scanning a huge number
+// of files. It's only used when the --files-with-findings flag is
+// specified. Responses in WARC and HAR files are listed by their URLs.
+type findingFiles struct {
+	sync.Mutex
+	w     io.Writer
+	files map[string]bool
+}
+
+func newFindingFiles(w io.Writer) *findingFiles {
+	return &findingFiles{
+		w:     w,
+		files: make(map[string]bool),
+	}
+}
+
+// add records that results were output for a file. It's
+// safe to call on a nil *findingFiles, which does nothing.
+func (f *findingFiles) add(filename string) {
+	if f == nil {
+		return
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.files[filename] = true
+}
+
+// write writes the names of the files, sorted and one per line
+func (f *findingFiles) write() error {
+	f.Lock()
+	defer f.Unlock()
+
+	files := make([]string, 0, len(f.files))
+	for filename := range f.files {
+		files = append(files, filename)
+	}
+	sort.Strings(files)
+
+	for _, filename := range files {
+		_, err := fmt.Fprintln(f.w, filename)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
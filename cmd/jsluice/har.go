@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+)
+
+// harFile is the part of an HTTP Archive (HAR) file that's needed to get
+// at the responses in it; see http://www.softwareishard.com/blog/har-12-spec/
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				URL string `json:"url"`
+			} `json:"request"`
+			Response struct {
+				Content struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// readHARFile reads the JavaScript, HTML, JSON, and CSS responses from a
+// HAR file, such as those exported from the network tab in browser devtools.
+// Like responses in WARC files, each one is labeled with the request's URL
+// and passed to fn. HAR files are a single JSON document so, unlike WARC files,
+// the whole file is decoded before any responses are passed on.
+func readHARFile(filename string, fn func(warcResponse)) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var har harFile
+	err = json.NewDecoder(f).Decode(&har)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range har.Log.Entries {
+		content := entry.Response.Content
+
+		inputType := contentTypeInput(content.MimeType)
+		if inputType == "" {
+			continue
+		}
+
+		// Browsers don't always save the response body
+		if content.Text == "" {
+			continue
+		}
+
+		source := []byte(content.Text)
+		if content.Encoding == "base64" {
+			source, err = base64.StdEncoding.DecodeString(content.Text)
+			if err != nil {
+				return err
+			}
+		}
+
+		fn(warcResponse{
+			url:       entry.Request.URL,
+			source:    source,
+			inputType: inputType,
+		})
+	}
+
+	return nil
+}
@@ -4,12 +4,16 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -21,38 +25,104 @@ import (
 
 type options struct {
 	// global
-	profile     bool
-	cookie      string
-	headers     []string
-	concurrency int
-	placeholder string
-	help        bool
-	warc        bool
-	rawInput    bool
-	certCheck   bool
+	profile      bool
+	cookie       string
+	headers      []string
+	concurrency  int
+	placeholder  string
+	help         bool
+	warc         bool
+	har          bool
+	sourceMaps   bool
+	rawInput     bool
+	certCheck    bool
+	listMatchers bool
+	inputType    string
+	sort         bool
+	ordered      bool
+	sarifOutput  bool
+	positions    bool
+	pretty       bool
+	debug        bool
+	fields       []string
+	beautify     bool
+	deobfuscate  bool
+	warnErrors   bool
+	progress     bool
+	plugins      *matcherPlugins
+	maxResults   int
+	excludes     []*regexp.Regexp
+
+	// nothing is output for files without any URLs or secrets
+	// when the --only-with-urls or --only-with-secrets flags are used
+	onlyWithURLs    bool
+	onlyWithSecrets bool
+
+	// the names of files that had results are collected here
+	// when the --files-with-findings flag is used
+	findings *findingFiles
+
+	// results are collected here instead of being output
+	// straight away when the --sort flag is used
+	sorted *sortedResults
+
+	// results are collected here to be output as a
+	// SARIF log when the --sarif flag is used
+	sarif *sarifResults
+
+	// the names of the parameters of URLs are collected here
+	// instead of outputting the URLs with the --params-only flag
+	params *paramNames
+
+	// results are tagged with a result field saying whether
+	// they're a URL or a secret in the all mode
+	tagResults bool
+
+	// secrets that have already been output are recorded
+	// here when the --unique-global flag is used
+	seen *seenSecrets
 
 	// urls
 	includeSource bool
+	includeRawURL bool
+	matchTrailing bool
 	ignoreStrings bool
 	resolvePaths  string
+	resolveBases  map[string]string
 	unique        bool
+	comments      bool
+	styles        bool
+	urlFilter     *regexp.Regexp
+	urlExclude    *regexp.Regexp
 
 	// secrets
-	patternsFile string
+	patternsFiles    []string
+	patterns         jsluice.UserPatterns
+	validatePatterns bool
+	secretKinds      []string
+	excludeKinds     []string
+	severities       map[string]jsluice.Severity
+	weakCredentials  bool
 
 	// query
 	query           string
 	rawOutput       bool
 	includeFilename bool
 	format          bool
+
+	// tree
+	withRanges bool
 }
 
 const (
 	modeURLs    = "urls"
 	modeSecrets = "secrets"
+	modeAll     = "all"
 	modeTree    = "tree"
 	modeQuery   = "query"
 	modeFormat  = "format"
+	modeSinks   = "sinks"
+	modeDiff    = "diff"
 )
 
 type stringSlice []string
@@ -83,27 +153,66 @@ func init() {
 			"Modes:",
 			"  urls      Extract URLs and paths",
 			"  secrets   Extract secrets and other interesting bits",
+			"  all       Extract URLs and secrets in one pass",
 			"  tree      Print syntax trees for input files",
 			"  query     Run tree-sitter a query against input files",
 			"  format    Format JavaScript source using jsbeautifier-go",
+			"  sinks     Find function calls and assignments that are passed paths or URLs",
+			"  diff      Compare the output of two previous scans; e.g. jsluice diff old.json new.json",
 			"",
 			"Global options:",
+			"      --config <file>          YAML or JSON file containing default values for flags; e.g. 'concurrency: 10'",
 			"  -c, --concurrency int        Number of files to process concurrently (default 1)",
 			"  -C, --cookie string          Cookies to use when making requests to the specified HTTP based arguments",
 			"  -H, --header string          Headers to use when making requests to the specified HTTP based arguments (can be specified multiple times)",
 			"  -P, --placeholder string     Set the expression placeholder to a custom string (default 'EXPR')",
 			"  -j, --raw-input              Read raw JavaScript source from stdin",
+			"  -t, --input-type <type>      Treat input as one of: auto, js, html, vue, svelte, css, json (default 'auto')",
 			"  -w, --warc                   Treat the input files as WARC (Web ARChive) files",
+			"      --har                    Treat the input files as HAR (HTTP Archive) files",
+			"      --sourcemap              Treat the input files as source maps and analyze the original sources they contain",
 			"  -i, --no-check-certificate	Ignore validation of server certificates",
+			"      --list-matchers          List the URL and secret matchers that will be used, then exit",
+			"      --sort                   Sort URLs and secrets before output (all results are held in memory)",
+			"      --ordered                Output results grouped by file, in the order the files were given",
+			"      --sarif                  Output a SARIF log for code scanning tools, in urls, secrets, or all mode (all results are held in memory)",
+			"      --include-position       Include the line and column where each URL or secret was found",
+			"      --pretty                 Indent JSON output for human inspection (output is no longer one result per line)",
+			"      --debug                  Log the decisions made by URL matchers to stderr",
+			"      --fields <fields>        Only output the listed fields; e.g. url,method,type",
+			"      --beautify               Beautify minified input before analyzing it (slower, but can be more accurate)",
+			"      --deobfuscate            Replace obfuscated expressions (e.g. JSFuck, String.fromCharCode) with the strings they evaluate to",
+			"      --warn-parse-errors      Warn on stderr when a file could not be parsed cleanly",
+			"      --progress               Show how many files have been processed on stderr (not when reading filenames from stdin)",
+			"      --max-results <n>        Stop looking for URLs or secrets in a file after finding this many (default no limit)",
+			"      --exclude <glob>         Skip files that match the glob; e.g. '*.min.js' or '**/vendor/**' (can be specified multiple times)",
+			"      --only-with-urls         Only output results for files that contain URLs, in urls, secrets, or all mode",
+			"      --only-with-secrets      Only output results for files that contain secrets, in urls, secrets, or all mode",
+			"      --files-with-findings <file>  Write the names of the files that had any results to a file, one per line, when the scan is done",
+			"      --matcher-plugin <file>  Load extra URL and secret matchers from a Go plugin (can be specified multiple times)",
 			"",
 			"URLs mode:",
 			"  -I, --ignore-strings         Ignore matches from string literals",
 			"  -S, --include-source         Include the source code where the URL was found",
+			"      --include-raw-url        Include the original string or expression for each URL before it was collapsed",
 			"  -R, --resolve-paths <url>    Resolve relative paths using the absolute URL provided",
+			"      --resolve-from-file <file>  JSON file mapping filenames to the base URLs to resolve their relative paths with",
 			"  -u, --unique                 Only output each URL once per input file",
+			"      --params-only            Only output the unique names of query and body parameters found across all files, one per line",
+			"      --include-comments       Also look for URLs in comments",
+			"      --include-styles         Also look for URLs in CSS in HTML and components; e.g. in <style> tags and style attributes",
+			"      --match-trailing-strings Also match location assignments that only end in a string; e.g. base + \"/login\" as EXPR/login",
+			"      --url-filter <regex>     Only output URLs that match the regex; e.g. '/api/'",
+			"      --url-exclude <regex>    Don't output URLs that match the regex",
 			"",
 			"Secrets mode:",
-			"  -p, --patterns <file>        JSON file containing user-defined secret patterns to look for",
+			"  -p, --patterns <file>        JSON or YAML file containing user-defined secret patterns to look for (can be repeated)",
+			"      --validate-patterns      Check the patterns files for problems, then exit",
+			"      --secret-kind <kinds>    Only output secrets of the listed kinds; e.g. AWSAccessKey,gcpKey",
+			"      --exclude-kind <kinds>   Don't output secrets of the listed kinds",
+			"      --unique-global          Only output each secret once, even if it's found in more than one file",
+			"      --weak-credentials       Also look for test and default credentials like admin:admin or changeme",
+			"      --severity-override <kind=severity>  Change the severity of secrets of a kind; e.g. stripeKey=medium (can be repeated)",
 			"",
 			"Query mode:",
 			"  -q, --query <query>          Tree sitter query to run; e.g. '(string) @matches'",
@@ -111,6 +220,9 @@ func init() {
 			"  -f, --include-filename       Include the filename in the output",
 			"  -F, --format                 Format source code in the output",
 			"",
+			"Tree mode:",
+			"      --with-ranges            Include the byte range and named child index for each node",
+			"",
 			"Examples:",
 			"  jsluice urls -C 'auth=true; user=admin;' -H 'Specific-Header-One: true' -H 'Specific-Header-Two: false' local_file.js https://remote.host/example.js",
 			"  jsluice query -q '(object) @m' one.js two.js",
@@ -123,26 +235,71 @@ func init() {
 func main() {
 	var opts options
 	var headers stringSlice
+	var urlFilter, urlExclude string
+	var resolveFile string
+	var pluginFiles []string
+	var excludes []string
+	var severityOverrides []string
+	var uniqueGlobal bool
+	var configFile string
+	var paramsOnly bool
+	var findingsFile string
 
 	// global options
+	flag.StringVar(&configFile, "config", "", "YAML or JSON file containing default values for flags")
 	flag.BoolVar(&opts.profile, "profile", false, "Profile CPU usage and save a cpu.pprof file in the current dir")
 	flag.IntVarP(&opts.concurrency, "concurrency", "c", 1, "Number of files to process concurrently")
 	flag.StringVarP(&opts.cookie, "cookie", "C", "", "Cookie(s) to use when making HTTP requests")
 	flag.VarP(&headers, "header", "H", "Headers to use when making HTTP requests")
 	flag.BoolVarP(&opts.rawInput, "raw-input", "j", false, "Read raw JavaScript source from stdin")
+	flag.StringVarP(&opts.inputType, "input-type", "t", "auto", "Treat input as one of: auto, js, html, vue, svelte, css, json")
 	flag.StringVarP(&opts.placeholder, "placeholder", "P", "EXPR", "Set the expression placeholder to a custom string")
 	flag.BoolVarP(&opts.help, "help", "h", false, "")
 	flag.BoolVarP(&opts.warc, "warc", "w", false, "")
+	flag.BoolVar(&opts.har, "har", false, "Treat the input files as HAR (HTTP Archive) files")
+	flag.BoolVar(&opts.sourceMaps, "sourcemap", false, "Treat the input files as source maps and analyze the original sources they contain")
 	flag.BoolVarP(&opts.certCheck, "no-check-certificate", "i", false, "Ignore validation of server certificates")
+	flag.BoolVar(&opts.listMatchers, "list-matchers", false, "List the URL and secret matchers that will be used, then exit")
+	flag.BoolVar(&opts.sort, "sort", false, "Sort URLs and secrets before output (all results are held in memory)")
+	flag.BoolVar(&opts.ordered, "ordered", false, "Output results grouped by file, in the order the files were given")
+	flag.BoolVar(&opts.sarifOutput, "sarif", false, "Output a SARIF log for code scanning tools")
+	flag.BoolVar(&opts.positions, "include-position", false, "Include the line and column where each URL or secret was found")
+	flag.BoolVar(&opts.pretty, "pretty", false, "Indent JSON output for human inspection")
+	flag.BoolVar(&opts.debug, "debug", false, "Log the decisions made by URL matchers to stderr")
+	flag.StringSliceVar(&opts.fields, "fields", nil, "Only output the listed fields; e.g. url,method,type")
+	flag.BoolVar(&opts.beautify, "beautify", false, "Beautify minified input before analyzing it")
+	flag.BoolVar(&opts.deobfuscate, "deobfuscate", false, "Replace obfuscated expressions with the strings they evaluate to")
+	flag.BoolVar(&opts.warnErrors, "warn-parse-errors", false, "Warn on stderr when a file could not be parsed cleanly")
+	flag.BoolVar(&opts.progress, "progress", false, "Show how many files have been processed on stderr")
+	flag.IntVar(&opts.maxResults, "max-results", 0, "Stop looking for URLs or secrets in a file after finding this many")
+	flag.StringArrayVar(&excludes, "exclude", nil, "Skip files that match the glob")
+	flag.BoolVar(&opts.onlyWithURLs, "only-with-urls", false, "Only output results for files that contain URLs")
+	flag.BoolVar(&opts.onlyWithSecrets, "only-with-secrets", false, "Only output results for files that contain secrets")
+	flag.StringVar(&findingsFile, "files-with-findings", "", "Write the names of the files that had any results to a file")
+	flag.StringArrayVar(&pluginFiles, "matcher-plugin", nil, "Load extra URL and secret matchers from a Go plugin")
 
 	// url options
 	flag.BoolVarP(&opts.includeSource, "include-source", "S", false, "Include the source code where the URL was found")
+	flag.BoolVar(&opts.includeRawURL, "include-raw-url", false, "Include the original string or expression for each URL before it was collapsed")
 	flag.BoolVarP(&opts.ignoreStrings, "ignore-strings", "I", false, "Ignore matches from string literals")
 	flag.StringVarP(&opts.resolvePaths, "resolve-paths", "R", "", "Resolve relative paths using the absolute URL provided")
+	flag.StringVar(&resolveFile, "resolve-from-file", "", "JSON file mapping filenames to the base URLs to resolve their relative paths with")
 	flag.BoolVarP(&opts.unique, "unique", "u", false, "")
+	flag.BoolVar(&paramsOnly, "params-only", false, "Only output the unique names of query and body parameters found across all files")
+	flag.BoolVar(&opts.comments, "include-comments", false, "Also look for URLs in comments")
+	flag.BoolVar(&opts.styles, "include-styles", false, "Also look for URLs in CSS in HTML and components")
+	flag.BoolVar(&opts.matchTrailing, "match-trailing-strings", false, "Also match location assignments that only end in a string; e.g. base + \"/login\"")
+	flag.StringVar(&urlFilter, "url-filter", "", "Only output URLs that match the regex")
+	flag.StringVar(&urlExclude, "url-exclude", "", "Don't output URLs that match the regex")
 
 	// secrets options
-	flag.StringVarP(&opts.patternsFile, "patterns", "p", "", "JSON file containing user-defined secret patterns to look for")
+	flag.StringArrayVarP(&opts.patternsFiles, "patterns", "p", nil, "JSON or YAML file containing user-defined secret patterns to look for (can be repeated)")
+	flag.BoolVar(&opts.validatePatterns, "validate-patterns", false, "Check the patterns files for problems, then exit")
+	flag.StringSliceVar(&opts.secretKinds, "secret-kind", nil, "Only output secrets of the listed kinds")
+	flag.StringSliceVar(&opts.excludeKinds, "exclude-kind", nil, "Don't output secrets of the listed kinds")
+	flag.BoolVar(&uniqueGlobal, "unique-global", false, "Only output each secret once, even if it's found in more than one file")
+	flag.BoolVar(&opts.weakCredentials, "weak-credentials", false, "Also look for test and default credentials like admin:admin or changeme")
+	flag.StringArrayVar(&severityOverrides, "severity-override", nil, "Change the severity of secrets of a kind; e.g. stripeKey=medium")
 
 	// query options
 	flag.StringVarP(&opts.query, "query", "q", "", "Tree sitter query to run; e.g. '(string) @matches'")
@@ -150,10 +307,77 @@ func main() {
 	flag.BoolVarP(&opts.includeFilename, "include-filename", "f", false, "Include the filename in the output")
 	flag.BoolVarP(&opts.format, "format", "F", false, "Format source code in the output")
 
+	// tree options
+	flag.BoolVar(&opts.withRanges, "with-ranges", false, "Include the byte range and named child index for each node")
+
 	flag.Parse()
 
+	// The config file is applied before anything else is done with the
+	// flags, so that it works just like they were given on the command line
+	if configFile != "" {
+		err := applyConfig(flag.CommandLine, configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load --config: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	opts.headers = headers
 
+	if resolveFile != "" {
+		bases, err := loadResolveBases(resolveFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load --resolve-from-file: %s\n", err)
+			os.Exit(1)
+		}
+		opts.resolveBases = bases
+	}
+
+	if len(pluginFiles) > 0 {
+		plugins, err := loadMatcherPlugins(pluginFiles)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load matcher plugin: %s\n", err)
+			os.Exit(1)
+		}
+		opts.plugins = plugins
+	}
+
+	if len(severityOverrides) > 0 {
+		severities, err := parseSeverityOverrides(severityOverrides)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --severity-override: %s\n", err)
+			os.Exit(1)
+		}
+		opts.severities = severities
+	}
+
+	if len(excludes) > 0 {
+		res, err := compileExcludes(excludes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid glob for --exclude: %s\n", err)
+			os.Exit(1)
+		}
+		opts.excludes = res
+	}
+
+	if urlFilter != "" {
+		re, err := regexp.Compile(urlFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid regex for --url-filter: %s\n", err)
+			os.Exit(1)
+		}
+		opts.urlFilter = re
+	}
+
+	if urlExclude != "" {
+		re, err := regexp.Compile(urlExclude)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid regex for --url-exclude: %s\n", err)
+			os.Exit(1)
+		}
+		opts.urlExclude = re
+	}
+
 	if opts.help {
 		flag.Usage()
 		return
@@ -163,16 +387,138 @@ func main() {
 		defer profile.Start(profile.ProfilePath(".")).Stop()
 	}
 
+	if opts.listMatchers {
+		err := listMatchers(opts, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list matchers: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.validatePatterns {
+		if len(opts.patternsFiles) == 0 {
+			fmt.Fprintln(os.Stderr, "usage: jsluice --validate-patterns -p <file>")
+			os.Exit(1)
+		}
+
+		var errs []error
+		for _, filename := range opts.patternsFiles {
+			for _, err := range validatePatterns(filename) {
+				// name the file when there's more than one to check
+				if len(opts.patternsFiles) > 1 {
+					err = fmt.Errorf("%s: %w", filename, err)
+				}
+				errs = append(errs, err)
+			}
+		}
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The patterns are parsed once and shared between all of the
+	// workers, so that a bad file is only reported once
+	if len(opts.patternsFiles) > 0 {
+		patterns, err := loadAllPatterns(opts.patternsFiles)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load patterns: %s\n", err)
+			os.Exit(1)
+		}
+		opts.patterns = patterns
+	}
+
 	args := flag.Args()
 	if len(args) == 0 {
 		fmt.Fprintln(os.Stderr, "usage: jsluice <mode> [...flags]")
 		os.Exit(1)
 	}
 
-	jsluice.ExpressionPlaceholder = opts.placeholder
+	if opts.warc && opts.sourceMaps {
+		fmt.Fprintln(os.Stderr, "--warc and --sourcemap can't be used together")
+		os.Exit(1)
+	}
+
+	if opts.har && (opts.warc || opts.sourceMaps) {
+		fmt.Fprintln(os.Stderr, "--har can't be used with --warc or --sourcemap")
+		os.Exit(1)
+	}
+
+	if _, exists := sourceHints[opts.inputType]; !exists {
+		fmt.Fprintf(os.Stderr, "no such input type: %s\n", opts.inputType)
+		os.Exit(1)
+	}
+
+	if opts.sort {
+		opts.sorted = &sortedResults{}
+	}
+
+	if uniqueGlobal {
+		opts.seen = newSeenSecrets()
+	}
 
 	mode := args[0]
 	files := args[1:]
+	opts.tagResults = mode == modeAll
+
+	if opts.sarifOutput {
+		if mode != modeURLs && mode != modeSecrets && mode != modeAll {
+			fmt.Fprintln(os.Stderr, "--sarif can only be used in the urls, secrets, and all modes")
+			os.Exit(1)
+		}
+		opts.sarif = &sarifResults{}
+	}
+
+	if paramsOnly {
+		if mode != modeURLs {
+			fmt.Fprintln(os.Stderr, "--params-only can only be used in the urls mode")
+			os.Exit(1)
+		}
+		if opts.sarif != nil {
+			fmt.Fprintln(os.Stderr, "--params-only and --sarif can't be used together")
+			os.Exit(1)
+		}
+		opts.params = newParamNames()
+	}
+
+	if opts.onlyWithURLs || opts.onlyWithSecrets || findingsFile != "" {
+		if mode != modeURLs && mode != modeSecrets && mode != modeAll {
+			fmt.Fprintln(os.Stderr, "--only-with-urls, --only-with-secrets, and --files-with-findings can only be used in the urls, secrets, and all modes")
+			os.Exit(1)
+		}
+	}
+
+	// The file is created before the scan starts so that
+	// a bad path doesn't waste a long scan
+	if findingsFile != "" {
+		f, err := os.Create(findingsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create --files-with-findings file: %s\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		opts.findings = newFindingFiles(f)
+	}
+
+	// diff mode works on jsluice's own output rather than on
+	// JavaScript, so it doesn't need any of the workers
+	if mode == modeDiff {
+		if len(files) != 2 {
+			fmt.Fprintln(os.Stderr, "usage: jsluice diff <old-output> <new-output>")
+			os.Exit(1)
+		}
+
+		err := diffScans(opts, files[0], files[1], os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to diff scans: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// spin up an output worker
 	output := make(chan string)
@@ -201,9 +547,11 @@ func main() {
 	modes := map[string]cmdFn{
 		modeURLs:    extractURLs,
 		modeSecrets: extractSecrets,
+		modeAll:     extractAll,
 		modeTree:    printTree,
 		modeQuery:   runQuery,
 		modeFormat:  format,
+		modeSinks:   findSinks,
 	}
 
 	if _, exists := modes[mode]; !exists {
@@ -212,35 +560,44 @@ func main() {
 	}
 	modeFn = modes[mode]
 
-	jobs := make(chan string)
+	jobs := make(chan job)
+
+	var ordered *orderedResults
+	if opts.ordered {
+		ordered = newOrderedResults()
+	}
+
+	// The total is only known when the files are given as arguments,
+	// so there's no progress shown when they're streamed in on stdin
+	var prog *progress
+	if opts.progress && len(files) > 0 && !opts.rawInput {
+		total := 0
+		for _, filename := range files {
+			if !excluded(opts, filename) {
+				total++
+			}
+		}
+		prog = newProgress(os.Stderr, total)
+	}
 
 	var wg sync.WaitGroup
 	for i := 0; i < opts.concurrency; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for filename := range jobs {
-
-				if opts.warc {
-					responses, err := readWARCFile(filename)
-					if err != nil {
-						errs <- err
-						continue
+			for j := range jobs {
+				failed := trackErrors(errs, func(errs chan error) {
+					if ordered == nil {
+						processFile(opts, modeFn, j.filename, output, errs)
+						return
 					}
 
-					for _, response := range responses {
-						modeFn(opts, response.url, response.source, output, errs)
-					}
-					continue
-				}
-
-				source, err := readFromFileOrURL(filename, opts.cookie, opts.headers, opts.certCheck)
-				if err != nil {
-					errs <- err
-					continue
-				}
-
-				modeFn(opts, filename, source, output, errs)
+					results := collect(func(out chan string) {
+						processFile(opts, modeFn, j.filename, out, errs)
+					})
+					ordered.add(j.index, results, output)
+				})
+				prog.done(failed)
 			}
 		}()
 	}
@@ -280,18 +637,195 @@ func main() {
 	}
 	input := bufio.NewScanner(r)
 
+	index := 0
 	for input.Scan() {
-		jobs <- input.Text()
+		filename := input.Text()
+		if excluded(opts, filename) {
+			continue
+		}
+
+		jobs <- job{index, filename}
+		index++
 	}
 	close(jobs)
 
 	wg.Wait()
+
+	if opts.params != nil {
+		opts.params.write(output)
+	} else if opts.sarif != nil {
+		opts.sarif.write(opts, output, errs)
+	} else if opts.sorted != nil {
+		opts.sorted.write(opts, output, errs)
+	}
+
+	if opts.findings != nil {
+		err := opts.findings.write()
+		if err != nil {
+			errs <- fmt.Errorf("failed to write --files-with-findings file: %w", err)
+		}
+	}
+
 	done <- struct{}{}
 	close(output)
 	close(errs)
 
 }
 
+// processFile reads a file (or each response in a WARC or HAR file)
+// and runs the mode function against it
+func processFile(opts options, modeFn cmdFn, filename string, output chan string, errs chan error) {
+	if opts.warc || opts.har {
+		read := readWARCFile
+		if opts.har {
+			read = readHARFile
+		}
+
+		// each response is analyzed as soon as it's read so that
+		// big archives don't have to fit in memory
+		err := read(filename, func(response warcResponse) {
+			// the response's content type says how to analyze it,
+			// unless the input type was given explicitly
+			o := opts
+			if o.inputType == "auto" {
+				o.inputType = response.inputType
+			}
+			modeFn(o, response.url, response.source, output, errs)
+		})
+		if err != nil {
+			errs <- err
+		}
+		return
+	}
+
+	if opts.sourceMaps {
+		sources, err := readSourceMap(opts, filename)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		for _, s := range sources {
+			modeFn(opts, s.path, s.source, output, errs)
+		}
+		return
+	}
+
+	source, err := readFromFileOrURL(filename, opts.cookie, opts.headers, opts.certCheck)
+	if err != nil {
+		errs <- err
+		return
+	}
+
+	modeFn(opts, filename, source, output, errs)
+}
+
+var sourceHints = map[string]jsluice.SourceHint{
+	"auto":   jsluice.AutoDetect,
+	"js":     jsluice.ForceJS,
+	"html":   jsluice.ForceHTML,
+	"vue":    jsluice.ForceVue,
+	"svelte": jsluice.ForceSvelte,
+	"css":    jsluice.ForceCSS,
+	"json":   jsluice.ForceJSON,
+}
+
+var extensionHints = map[string]jsluice.SourceHint{
+	".mjs":    jsluice.ForceJS,
+	".cjs":    jsluice.ForceJS,
+	".vue":    jsluice.ForceVue,
+	".svelte": jsluice.ForceSvelte,
+	".css":    jsluice.ForceCSS,
+	".json":   jsluice.ForceJSON,
+}
+
+// newAnalyzer returns a *jsluice.Analyzer for the provided source, using
+// the --input-type option or the file extension to decide how to treat it.
+// Any options that affect the behaviour of the analyzer are applied here.
+func newAnalyzer(opts options, filename string, source []byte) *jsluice.Analyzer {
+	hint := sourceHints[opts.inputType]
+
+	if hint == jsluice.AutoDetect {
+		if h, exists := extensionHints[strings.ToLower(path.Ext(filename))]; exists {
+			hint = h
+		}
+	}
+
+	analyzer := jsluice.NewAnalyzerWithHint(source, hint)
+	analyzer.IncludeComments = opts.comments
+
+	// CSS input always has its URLs extracted
+	if opts.styles {
+		analyzer.IncludeStyles = true
+	}
+	analyzer.ExpressionPlaceholder = opts.placeholder
+	analyzer.Beautify = opts.beautify
+	analyzer.Deobfuscate = opts.deobfuscate
+	analyzer.MaxResults = opts.maxResults
+	analyzer.MatchTrailingStrings = opts.matchTrailing
+	opts.plugins.addTo(analyzer)
+
+	if opts.debug {
+		analyzer.Debug = os.Stderr
+	}
+
+	// Files that don't parse cleanly (e.g. TypeScript or JSX) often
+	// produce fewer results than expected, so it's useful to know
+	if opts.warnErrors && analyzer.HasErrors() {
+		fmt.Fprintf(
+			os.Stderr,
+			"warning: %s has %d parse errors; parts of it may not have been analyzed\n",
+			filename, len(analyzer.ErrorNodes()),
+		)
+	}
+
+	return analyzer
+}
+
+// warnTruncated warns on stderr if results were dropped from a
+// file because of the --max-results flag
+func warnTruncated(opts options, filename string, analyzer *jsluice.Analyzer) {
+	if !analyzer.Truncated() {
+		return
+	}
+
+	fmt.Fprintf(
+		os.Stderr,
+		"warning: %s has more than %d results; the rest were skipped\n",
+		filename, opts.maxResults,
+	)
+}
+
+// marshal converts a result to JSON, keeping only the fields selected
+// with --fields, and indenting it if the --pretty flag was specified.
+// Indented output isn't line-delimited, so it's meant for reading, not
+// for piping into tools like jq.
+func marshal(opts options, v any) ([]byte, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.tagResults {
+		j = tagResult(j, v)
+	}
+
+	if len(opts.fields) > 0 {
+		j, err = selectFields(j, opts.fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !opts.pretty {
+		return j, nil
+	}
+
+	buf := &bytes.Buffer{}
+	err = json.Indent(buf, j, "", "  ")
+	return buf.Bytes(), err
+}
+
 func readFromFileOrURL(path string, cookie string, headers []string, ignoreCert bool) ([]byte, error) {
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
 		client := &http.Client{}
@@ -336,22 +870,43 @@ func readFromFileOrURL(path string, cookie string, headers []string, ignoreCert
 }
 
 type warcResponse struct {
-	url    string
-	source []byte
+	url       string
+	source    []byte
+	inputType string
 }
 
-func readWARCFile(filename string) ([]warcResponse, error) {
-	out := make([]warcResponse, 0)
+// contentTypeInput returns the --input-type that responses with a given
+// content type should be analyzed as, or an empty string for responses
+// that aren't worth analyzing (e.g. images)
+func contentTypeInput(contentType string) string {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "javascript"), strings.Contains(ct, "ecmascript"):
+		return "js"
+	case strings.Contains(ct, "html"):
+		return "html"
+	case strings.Contains(ct, "json"):
+		return "json"
+	case strings.Contains(ct, "css"):
+		return "css"
+	default:
+		return ""
+	}
+}
 
+// readWARCFile reads the JavaScript, HTML, JSON, and CSS responses from a
+// WARC file, calling fn with each one as it's read rather than collecting
+// them all first, so only one response body is held in memory at a time.
+func readWARCFile(filename string, fn func(warcResponse)) error {
 	f, err := os.Open(filename)
 	if err != nil {
-		return out, err
+		return err
 	}
 	defer f.Close()
 
 	r, err := warc.NewReader(f)
 	if err != nil {
-		return out, err
+		return err
 	}
 	defer r.Close()
 
@@ -368,25 +923,27 @@ func readWARCFile(filename string) ([]warcResponse, error) {
 		buf := bufio.NewReader(record.Content)
 		response, err := http.ReadResponse(buf, nil)
 		if err != nil {
-			return out, err
+			return err
 		}
 
-		ct := strings.ToLower(response.Header.Get("content-type"))
-		if !strings.Contains(ct, "javascript") && !strings.Contains(ct, "html") {
+		inputType := contentTypeInput(response.Header.Get("content-type"))
+		if inputType == "" {
+			response.Body.Close()
 			continue
 		}
 
 		body, err := ioutil.ReadAll(response.Body)
 		if err != nil {
-			return out, err
+			return err
 		}
 		response.Body.Close()
 
-		out = append(out, warcResponse{
-			url:    record.Header.Get("WARC-Target-URI"),
-			source: body,
+		fn(warcResponse{
+			url:       record.Header.Get("WARC-Target-URI"),
+			source:    body,
+			inputType: inputType,
 		})
 	}
 
-	return out, nil
+	return nil
 }
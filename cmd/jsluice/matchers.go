@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// listMatchers writes a table of all of the URL and secret matchers
+// that would be used for a scan, including any matchers loaded from
+// plugins and any user-defined patterns
+func listMatchers(opts options, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "MODE\tNAME\tMATCHES\tKIND\tSEVERITY")
+
+	urlMatchers := jsluice.AllURLMatchers()
+	secretMatchers := jsluice.AllSecretMatchers()
+
+	if opts.weakCredentials {
+		secretMatchers = append(secretMatchers, jsluice.WeakCredentialMatcher())
+	}
+
+	if opts.plugins != nil {
+		urlMatchers = append(urlMatchers, opts.plugins.urlMatchers...)
+		secretMatchers = append(secretMatchers, opts.plugins.secretMatchers...)
+	}
+
+	if len(opts.patternsFiles) > 0 {
+		patterns, err := loadAllPatterns(opts.patternsFiles)
+		if err != nil {
+			return err
+		}
+		secretMatchers = append(secretMatchers, patterns.SecretMatchers()...)
+	}
+
+	// URL matchers don't have a kind or severity; the type
+	// of each URL depends on where it was found
+	for _, m := range urlMatchers {
+		fmt.Fprintf(tw, "urls\t%s\t%s\t-\t-\n", m.Name, m.Type)
+	}
+
+	for _, m := range secretMatchers {
+		fmt.Fprintf(tw, "secrets\t%s\t%s\t%s\t%s\n", m.Name, m.Query, orDash(m.Kind), orDash(string(m.Severity)))
+	}
+
+	return tw.Flush()
+}
+
+// orDash returns "-" in place of an empty string, so
+// that the columns of a table stay lined up
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
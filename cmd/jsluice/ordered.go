@@ -0,0 +1,74 @@
+package main
+
+import (
+	"sync"
+)
+
+// A job is a single input file, along with its position in the input
+type job struct {
+	index    int
+	filename string
+}
+
+// orderedResults holds on to the output for each input file until the
+// output for every file before it has been written, so that results are
+// grouped by file and written in input order even when files are being
+// processed concurrently. It's only used when the --ordered flag is
+// specified.
+type orderedResults struct {
+	sync.Mutex
+	next    int
+	pending map[int][]string
+}
+
+func newOrderedResults() *orderedResults {
+	return &orderedResults{
+		pending: make(map[int][]string),
+	}
+}
+
+// add stores the output for the file at the provided index, and sends the
+// output for any files that are now next in line to the output channel
+func (r *orderedResults) add(index int, results []string, output chan string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.pending[index] = results
+
+	for {
+		results, exists := r.pending[r.next]
+		if !exists {
+			return
+		}
+
+		for _, result := range results {
+			output <- result
+		}
+
+		delete(r.pending, r.next)
+		r.next++
+	}
+}
+
+// collect calls fn with a new output channel, and returns
+// everything that was sent to the channel once fn returns
+func collect(fn func(chan string)) []string {
+	ch := make(chan string)
+	done := make(chan []string)
+
+	go func() {
+		results := make([]string, 0)
+		for result := range ch {
+			if result == "" {
+				continue
+			}
+			results = append(results, result)
+		}
+		done <- results
+	}()
+
+	fn(ch)
+	close(ch)
+
+	return <-done
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// paramNames collects the names of the query and body parameters of every
+// URL found by all of the workers, so that a single list of unique names
+// can be output once the scan is done; e.g. as a wordlist for fuzzing.
+// It's only used when the --params-only flag is specified.
+type paramNames struct {
+	sync.Mutex
+	names map[string]bool
+}
+
+func newParamNames() *paramNames {
+	return &paramNames{
+		names: make(map[string]bool),
+	}
+}
+
+func (p *paramNames) addURL(u *jsluice.URL) {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, name := range u.QueryParams {
+		p.names[name] = true
+	}
+	for _, name := range u.BodyParams {
+		p.names[name] = true
+	}
+}
+
+// write sends the parameter names to the output channel, one per line
+// and sorted, rather than as JSON, so they can be used as a wordlist
+func (p *paramNames) write(output chan string) {
+	p.Lock()
+	defer p.Unlock()
+
+	names := make([]string, 0, len(p.names))
+	for name := range p.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		output <- name
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// matcherPlugins holds the matchers loaded from Go plugins with the
+// --matcher-plugin flag, so that they can be added to each analyzer
+type matcherPlugins struct {
+	urlMatchers    []jsluice.URLMatcher
+	secretMatchers []jsluice.SecretMatcher
+}
+
+// loadMatcherPlugins opens each of the Go plugins (built with
+// -buildmode=plugin) and collects the matchers they provide. A plugin
+// can export a URLMatchers function, a SecretMatchers function, or both:
+//
+//	func URLMatchers() []jsluice.URLMatcher
+//	func SecretMatchers() []jsluice.SecretMatcher
+//
+// Plugins must be built with the same version of Go and of jsluice
+// as the jsluice binary that loads them.
+func loadMatcherPlugins(paths []string) (*matcherPlugins, error) {
+	out := &matcherPlugins{}
+
+	for _, path := range paths {
+		p, err := plugin.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+
+		if sym, err := p.Lookup("URLMatchers"); err == nil {
+			fn, ok := sym.(func() []jsluice.URLMatcher)
+			if !ok {
+				return nil, fmt.Errorf("%s: URLMatchers must be a func() []jsluice.URLMatcher", path)
+			}
+			out.urlMatchers = append(out.urlMatchers, fn()...)
+			found = true
+		}
+
+		if sym, err := p.Lookup("SecretMatchers"); err == nil {
+			fn, ok := sym.(func() []jsluice.SecretMatcher)
+			if !ok {
+				return nil, fmt.Errorf("%s: SecretMatchers must be a func() []jsluice.SecretMatcher", path)
+			}
+			out.secretMatchers = append(out.secretMatchers, fn()...)
+			found = true
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%s: plugin exports neither URLMatchers nor SecretMatchers", path)
+		}
+	}
+
+	return out, nil
+}
+
+// addTo adds the plugin matchers to an analyzer
+func (m *matcherPlugins) addTo(analyzer *jsluice.Analyzer) {
+	if m == nil {
+		return
+	}
+
+	for _, u := range m.urlMatchers {
+		analyzer.AddURLMatcher(u)
+	}
+	analyzer.AddSecretMatchers(m.secretMatchers)
+}
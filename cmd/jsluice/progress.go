@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// progress keeps count of the files that have been processed, and writes
+// a running total to stderr. It's only used when the --progress flag is
+// specified and the total number of files is known up front, so it's
+// never used when filenames are being streamed in on stdin.
+type progress struct {
+	sync.Mutex
+	w      io.Writer
+	total  int
+	ok     int
+	failed int
+}
+
+func newProgress(w io.Writer, total int) *progress {
+	return &progress{w: w, total: total}
+}
+
+// done records that a file has been processed and writes the new
+// totals. It's safe to call on a nil *progress, which does nothing.
+func (p *progress) done(failed bool) {
+	if p == nil {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if failed {
+		p.failed++
+	} else {
+		p.ok++
+	}
+
+	fmt.Fprintf(p.w, "\rprocessed %d/%d (%d ok, %d failed)", p.ok+p.failed, p.total, p.ok, p.failed)
+	if p.ok+p.failed == p.total {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// trackErrors calls fn with a new error channel, passing anything sent
+// to it on to errs, and returns true if there were any errors
+func trackErrors(errs chan error, fn func(chan error)) bool {
+	ch := make(chan error)
+	done := make(chan bool)
+
+	go func() {
+		failed := false
+		for err := range ch {
+			failed = true
+			errs <- err
+		}
+		done <- failed
+	}()
+
+	fn(ch)
+	close(ch)
+
+	return <-done
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -10,7 +9,18 @@ import (
 
 func runQuery(opts options, filename string, source []byte, output chan string, errs chan error) {
 	// TODO: add options to output nodes as trees and/or JSON blobs
-	analyzer := jsluice.NewAnalyzer(source)
+	analyzer := newAnalyzer(opts, filename, source)
+
+	// Querying the formatted source means that the
+	// nodes we output are already nicely formatted
+	if opts.format {
+		formatted, err := analyzer.Formatted()
+		if err != nil {
+			errs <- err
+			return
+		}
+		analyzer = formatted
+	}
 
 	buf := &strings.Builder{}
 
@@ -48,7 +58,7 @@ func runQuery(opts options, filename string, source []byte, output chan string,
 			}
 		}
 
-		b, err := json.Marshal(out)
+		b, err := marshal(opts, out)
 		if err != nil {
 			return
 		}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// sarifResults collects URLs and secrets from all of the workers so that
+// they can be output as a single SARIF log, which is what GitHub code
+// scanning and most security dashboards accept. Like sortedResults, it
+// holds everything in memory until all of the input has been processed,
+// so it's only used when the --sarif flag is specified.
+type sarifResults struct {
+	sync.Mutex
+	urls    []*jsluice.URL
+	secrets []*jsluice.Secret
+}
+
+func (r *sarifResults) addURL(u *jsluice.URL) {
+	r.Lock()
+	r.urls = append(r.urls, u)
+	r.Unlock()
+}
+
+func (r *sarifResults) addSecret(s *jsluice.Secret) {
+	r.Lock()
+	r.secrets = append(r.secrets, s)
+	r.Unlock()
+}
+
+// The types below are the parts of the SARIF 2.1.0 format that jsluice
+// uses. The full schema is at https://json.schemastore.org/sarif-2.1.0.json
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	ColumnKind         string                           `json:"columnKind"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarifLevels maps jsluice's severities to SARIF's levels
+var sarifLevels = map[jsluice.Severity]string{
+	jsluice.SeverityInfo:   "note",
+	jsluice.SeverityLow:    "note",
+	jsluice.SeverityMedium: "warning",
+	jsluice.SeverityHigh:   "error",
+}
+
+// sarifURLRule is the rule ID used for every URL, because URLs
+// aren't findings in their own right the way secrets are
+const sarifURLRule = "url"
+
+// sarifSourceRoot is the base ID for files under the current directory,
+// which code scanning tools usually take to be the root of the repository
+const sarifSourceRoot = "%SRCROOT%"
+
+// write sends the collected results to the output channel as a SARIF log.
+// Secrets use their kind as the rule ID. The values of secrets are left
+// out of the log, because it's often uploaded somewhere else; the location
+// is enough to find them.
+func (r *sarifResults) write(opts options, output chan string, errs chan error) {
+	r.Lock()
+	defer r.Unlock()
+
+	sort.SliceStable(r.urls, func(i, j int) bool {
+		return r.urls[i].Less(r.urls[j])
+	})
+
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return r.secrets[i].Less(r.secrets[j])
+	})
+
+	// files under the current directory are given relative to it
+	root, err := os.Getwd()
+	if err != nil {
+		root = ""
+	}
+
+	rules := make(map[string]string)
+	results := make([]sarifResult, 0, len(r.urls)+len(r.secrets))
+
+	for _, s := range r.secrets {
+		rules[s.Kind] = fmt.Sprintf("%s secret", s.Kind)
+
+		level, ok := sarifLevels[s.Severity]
+		if !ok {
+			level = "warning"
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    s.Kind,
+			Level:     level,
+			Message:   sarifMessage{fmt.Sprintf("Found a %s secret with %s severity", s.Kind, s.Severity)},
+			Locations: sarifLocations(root, s.Filename, s.Position),
+		})
+	}
+
+	for _, u := range r.urls {
+		rules[sarifURLRule] = "URL or path"
+
+		properties := map[string]string{"type": u.Type}
+		if u.Method != "" {
+			properties["method"] = u.Method
+		}
+
+		results = append(results, sarifResult{
+			RuleID:     sarifURLRule,
+			Level:      "note",
+			Message:    sarifMessage{u.URL},
+			Locations:  sarifLocations(root, u.Filename, u.Position),
+			Properties: properties,
+		})
+	}
+
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	driver := sarifDriver{
+		Name:           "jsluice",
+		InformationURI: "https://github.com/BishopFox/jsluice",
+		Rules:          make([]sarifRule, 0, len(ids)),
+	}
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarifRule{id, sarifMessage{rules[id]}})
+	}
+
+	run := sarifRun{
+		Tool:       sarifTool{driver},
+		ColumnKind: "utf16CodeUnits",
+		Results:    results,
+	}
+	if root != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{
+			sarifSourceRoot: {URI: fileURI(root) + "/"},
+		}
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	j, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		errs <- err
+		return
+	}
+	output <- string(j)
+}
+
+// sarifLocations returns the location of a result in a file. The region is
+// left out when the position isn't known (e.g. for URLs found in CSS).
+func sarifLocations(root, filename string, position *jsluice.Position) []sarifLocation {
+	l := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifact(root, filename),
+		},
+	}
+
+	if position != nil {
+		l.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   position.Line,
+			StartColumn: position.Column,
+		}
+	}
+
+	return []sarifLocation{l}
+}
+
+// sarifArtifact returns the location of a file as a URI. Files under the
+// root directory are relative to the %SRCROOT% base ID, other files are
+// file:// URIs, and URLs (e.g. responses in WARC files) are left as they are.
+func sarifArtifact(root, filename string) sarifArtifactLocation {
+	if u, err := url.Parse(filename); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return sarifArtifactLocation{URI: filename}
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return sarifArtifactLocation{URI: filepath.ToSlash(filename)}
+	}
+
+	if root != "" {
+		rel, err := filepath.Rel(root, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return sarifArtifactLocation{
+				URI:       (&url.URL{Path: filepath.ToSlash(rel)}).String(),
+				URIBaseID: sarifSourceRoot,
+			}
+		}
+	}
+
+	return sarifArtifactLocation{URI: fileURI(abs)}
+}
+
+// fileURI returns a file:// URI for an absolute path
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+
+	// e.g. C:/src on Windows
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// utf16Position returns a copy of a position with its column counted in
+// UTF-16 code units rather than bytes, because that's how SARIF counts
+// columns. The position is left alone if it's not in the source; e.g.
+// for inline scripts in HTML, where positions are relative to the
+// JavaScript that was extracted from the page.
+func utf16Position(source []byte, p *jsluice.Position) *jsluice.Position {
+	if p == nil {
+		return nil
+	}
+
+	line := source
+	for i := 1; i < p.Line; i++ {
+		end := bytes.IndexByte(line, '\n')
+		if end == -1 {
+			return p
+		}
+		line = line[end+1:]
+	}
+
+	if p.Column-1 > len(line) {
+		return p
+	}
+
+	units := 0
+	for before := line[:p.Column-1]; len(before) > 0; {
+		r, size := utf8.DecodeRune(before)
+		if n := utf16.RuneLen(r); n > 0 {
+			units += n
+		} else {
+			units++
+		}
+		before = before[size:]
+	}
+
+	return &jsluice.Position{Line: p.Line, Column: units + 1}
+}
@@ -1,43 +1,184 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/BishopFox/jsluice"
 )
 
 func extractSecrets(opts options, filename string, source []byte, output chan string, errs chan error) {
-	analyzer := jsluice.NewAnalyzer(source)
+	extract(opts, filename, source, false, true, output, errs)
+}
 
-	// TODO: come up with a nice way to cache the patterns file and
-	// only throw any open or parse errors once
-	if opts.patternsFile != "" {
-		f, err := os.Open(opts.patternsFile)
-		if err != nil {
-			errs <- err
-			return
+// extractAll outputs both the URLs and the secrets in a file, using the
+// same analyzer for both so that the source is only parsed once
+func extractAll(opts options, filename string, source []byte, output chan string, errs chan error) {
+	extract(opts, filename, source, true, true, output, errs)
+}
+
+// findSecrets returns the secrets found by an analyzer that has already
+// been created, after the secrets options (e.g. --secret-kind) have been
+// applied. It should only be called once for each analyzer.
+func findSecrets(opts options, filename string, analyzer *jsluice.Analyzer) []*jsluice.Secret {
+	if len(opts.patterns) > 0 {
+		analyzer.AddSecretMatchers(opts.patterns.SecretMatchers())
+	}
+
+	if opts.weakCredentials {
+		analyzer.AddSecretMatcher(jsluice.WeakCredentialMatcher())
+	}
+
+	out := make([]*jsluice.Secret, 0)
+	for _, match := range analyzer.GetSecrets() {
+		if !kindSelected(opts, match.Kind) {
+			continue
 		}
 
-		patterns, err := jsluice.ParseUserPatterns(f)
-		if err != nil {
-			errs <- err
-			return
+		match.Filename = filename
+		if severity, ok := opts.severities[strings.ToLower(match.Kind)]; ok {
+			match.Severity = severity
 		}
 
-		analyzer.AddSecretMatchers(patterns.SecretMatchers())
+		if !opts.positions && opts.sarif == nil && opts.sorted == nil {
+			match.Position = nil
+		}
+
+		out = append(out, match)
 	}
 
-	matches := analyzer.GetSecrets()
-	for _, match := range matches {
+	return out
+}
 
-		match.Filename = filename
+// writeSecrets outputs secrets, or hands them to whichever collector is in
+// use, and returns how many there were. Secrets that have already been
+// output for another file aren't counted when --unique-global is used.
+func writeSecrets(opts options, secrets []*jsluice.Secret, output chan string) int {
+	n := 0
+	for _, match := range secrets {
+		if !opts.seen.firstTime(match) {
+			continue
+		}
+		n++
+
+		if opts.sarif != nil {
+			opts.sarif.addSecret(match)
+			continue
+		}
+
+		if opts.sorted != nil {
+			opts.sorted.addSecret(match)
+			continue
+		}
 
-		j, err := json.Marshal(match)
+		j, err := marshal(opts, match)
 		if err != nil {
 			continue
 		}
 		output <- fmt.Sprintf("%s", j)
 	}
+
+	return n
+}
+
+// kindSelected returns true if secrets of the provided kind should be output,
+// according to the --secret-kind and --exclude-kind flags. Kinds are the same
+// as the names shown by --list-matchers, and are compared case-insensitively.
+func kindSelected(opts options, kind string) bool {
+	for _, k := range opts.excludeKinds {
+		if strings.EqualFold(k, kind) {
+			return false
+		}
+	}
+
+	if len(opts.secretKinds) == 0 {
+		return true
+	}
+
+	for _, k := range opts.secretKinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSeverityOverrides parses the kind=severity values given with the
+// --severity-override flag into a map of lowercased kinds to severities
+func parseSeverityOverrides(overrides []string) (map[string]jsluice.Severity, error) {
+	out := make(map[string]jsluice.Severity)
+
+	for _, o := range overrides {
+		kind, severity, found := strings.Cut(o, "=")
+		kind = strings.TrimSpace(kind)
+		if !found || kind == "" {
+			return nil, fmt.Errorf("%q should be in the form kind=severity", o)
+		}
+
+		s := jsluice.Severity(strings.ToLower(strings.TrimSpace(severity)))
+		if !s.Valid() {
+			return nil, fmt.Errorf("%q is not a valid severity; use info, low, medium, or high", severity)
+		}
+		out[strings.ToLower(kind)] = s
+	}
+
+	return out, nil
+}
+
+// loadPatterns reads a user-defined patterns file, which is
+// treated as YAML if it has a .yaml or .yml extension, or as
+// JSON otherwise
+func loadPatterns(filename string) (jsluice.UserPatterns, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".yaml", ".yml":
+		return jsluice.ParseUserPatternsYAML(f)
+	default:
+		return jsluice.ParseUserPatterns(f)
+	}
+}
+
+// loadAllPatterns reads each of the user-defined patterns files and
+// merges them, so that teams can keep separate files for different
+// sets of patterns (e.g. cloud, CI, and company-specific ones)
+func loadAllPatterns(filenames []string) (jsluice.UserPatterns, error) {
+	all := make(jsluice.UserPatterns, 0)
+
+	for _, filename := range filenames {
+		patterns, err := loadPatterns(filename)
+		if err != nil && len(filenames) > 1 {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, patterns...)
+	}
+
+	return all, nil
+}
+
+// validatePatterns checks a user-defined patterns file, returning
+// all of the problems found rather than just the first one
+func validatePatterns(filename string) []error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return []error{err}
+	}
+	defer f.Close()
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".yaml", ".yml":
+		return jsluice.ValidateUserPatternsYAML(f)
+	default:
+		return jsluice.ValidateUserPatterns(f)
+	}
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// A sink is a function call or an assignment that is passed a
+// string that looks like a path or URL. Sinks that jsluice doesn't
+// already have a URL matcher for are candidates for new matchers.
+type sink struct {
+	Sink     string `json:"sink"`
+	Kind     string `json:"kind"`
+	Argument *int   `json:"argument,omitempty"`
+	Value    string `json:"value"`
+	Filename string `json:"filename,omitempty"`
+	jsluice.Position
+}
+
+var reJSName = regexp.MustCompile(`^[a-zA-Z0-9_$.-]+$`)
+
+func findSinks(opts options, filename string, source []byte, output chan string, errs chan error) {
+	analyzer := newAnalyzer(opts, filename, source)
+
+	analyzer.Query("[(assignment_expression) (call_expression)] @matches", func(n *jsluice.Node) {
+		var s *sink
+
+		switch n.Type() {
+		case "assignment_expression":
+			right := n.ChildByFieldName("right")
+			if !right.IsStringy() || !couldBePath(right.CollapsedString()) {
+				return
+			}
+
+			s = &sink{
+				Sink:  n.ChildByFieldName("left").Content(),
+				Kind:  "assignment",
+				Value: right.CollapsedString(),
+			}
+
+		case "call_expression":
+			// It's common to find things like immediately called anonymous
+			// functions, and we don't care about those because we could
+			// never match on them
+			callName := n.ChildByFieldName("function").Content()
+			if !reJSName.MatchString(callName) {
+				return
+			}
+
+			for i, arg := range n.ChildByFieldName("arguments").NamedChildren() {
+				if !arg.IsStringy() || !couldBePath(arg.CollapsedString()) {
+					continue
+				}
+
+				position := i
+				s = &sink{
+					Sink:     callName,
+					Kind:     "call",
+					Argument: &position,
+					Value:    arg.CollapsedString(),
+				}
+				break
+			}
+		}
+
+		if s == nil {
+			return
+		}
+
+		s.Filename = filename
+		s.Position = n.Position()
+
+		j, err := marshal(opts, s)
+		if err != nil {
+			errs <- err
+			return
+		}
+		output <- fmt.Sprintf("%s", j)
+	})
+}
+
+// couldBePath returns true if the string starts like
+// an absolute URL, or an absolute or relative path
+func couldBePath(in string) bool {
+	if (strings.HasPrefix(in, "http:") && len(in) > 7) ||
+		(strings.HasPrefix(in, "https:") && len(in) > 8) ||
+		(strings.HasPrefix(in, "/") && len(in) > 3) ||
+		(strings.HasPrefix(in, "./") && len(in) > 4) {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// sortedResults collects URLs and secrets from all of the workers so that
+// they can be sorted before any are output. Everything has to be held in
+// memory until all of the input has been processed, so it's only used
+// when the --sort flag is specified.
+type sortedResults struct {
+	sync.Mutex
+	urls    []*jsluice.URL
+	secrets []*jsluice.Secret
+}
+
+func (r *sortedResults) addURL(u *jsluice.URL) {
+	r.Lock()
+	r.urls = append(r.urls, u)
+	r.Unlock()
+}
+
+func (r *sortedResults) addSecret(s *jsluice.Secret) {
+	r.Lock()
+	r.secrets = append(r.secrets, s)
+	r.Unlock()
+}
+
+// write sorts the collected results and sends them to the output channel
+func (r *sortedResults) write(opts options, output chan string, errs chan error) {
+	r.Lock()
+	defer r.Unlock()
+
+	sort.SliceStable(r.urls, func(i, j int) bool {
+		return r.urls[i].Less(r.urls[j])
+	})
+
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return r.secrets[i].Less(r.secrets[j])
+	})
+
+	// positions were only kept for sorting
+	// if they weren't asked for
+	for _, u := range r.urls {
+		if !opts.positions {
+			u.Position = nil
+		}
+
+		j, err := marshal(opts, u)
+		if err != nil {
+			errs <- err
+			continue
+		}
+		output <- fmt.Sprintf("%s", j)
+	}
+
+	for _, s := range r.secrets {
+		if !opts.positions {
+			s.Position = nil
+		}
+
+		j, err := marshal(opts, s)
+		if err != nil {
+			continue
+		}
+		output <- fmt.Sprintf("%s", j)
+	}
+}
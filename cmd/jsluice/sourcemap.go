@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// sourceMap is the subset of a source map (https://sourcemaps.info/spec.html)
+// that's needed to get at the original sources. Index maps have sections
+// containing other source maps instead of sources.
+type sourceMap struct {
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+	Sections       []struct {
+		Map sourceMap `json:"map"`
+	} `json:"sections"`
+}
+
+// originalSource is a single original source from a source map
+type originalSource struct {
+	path   string
+	source []byte
+}
+
+// readSourceMap returns the original sources that are included in the
+// sourcesContent of a source map, along with their paths from sources.
+// Sources without any content (e.g. because the bundler was configured
+// to leave it out) are skipped.
+func readSourceMap(opts options, filename string) ([]originalSource, error) {
+	raw, err := readFromFileOrURL(filename, opts.cookie, opts.headers, opts.certCheck)
+	if err != nil {
+		return nil, err
+	}
+
+	// Source maps can start with a line like )]}' to stop them being
+	// used for cross-site script inclusion, which has to be removed
+	if bytes.HasPrefix(raw, []byte(")]}")) {
+		if i := bytes.IndexByte(raw, '\n'); i != -1 {
+			raw = raw[i+1:]
+		}
+	}
+
+	var m sourceMap
+	err = json.Unmarshal(raw, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.originalSources(), nil
+}
+
+func (m sourceMap) originalSources() []originalSource {
+	out := make([]originalSource, 0)
+
+	for i, path := range m.Sources {
+		if i >= len(m.SourcesContent) || m.SourcesContent[i] == nil {
+			continue
+		}
+
+		if m.SourceRoot != "" && !strings.Contains(path, "://") {
+			path = strings.TrimSuffix(m.SourceRoot, "/") + "/" + strings.TrimPrefix(path, "/")
+		}
+
+		out = append(out, originalSource{
+			path:   path,
+			source: []byte(*m.SourcesContent[i]),
+		})
+	}
+
+	for _, section := range m.Sections {
+		out = append(out, section.Map.originalSources()...)
+	}
+
+	return out
+}
@@ -12,7 +12,11 @@ func printTree(opts options, filename string, source []byte, output chan string,
 	buf := strings.Builder{}
 	buf.WriteString(fmt.Sprintf("%s:\n", filename))
 
-	buf.WriteString(jsluice.PrintTree(source))
+	if opts.withRanges {
+		buf.WriteString(jsluice.PrintTreeWithRanges(source))
+	} else {
+		buf.WriteString(jsluice.PrintTree(source))
+	}
 
 	output <- buf.String()
 }
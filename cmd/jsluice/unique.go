@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// seenSecrets is shared between all of the workers to keep track of the
+// secrets that have already been output, so that a secret found in many
+// files (e.g. an API key in every chunk of a webpack build) is only
+// output once. It's only used when the --unique-global flag is specified.
+type seenSecrets struct {
+	sync.Mutex
+	seen map[string]bool
+}
+
+func newSeenSecrets() *seenSecrets {
+	return &seenSecrets{
+		seen: make(map[string]bool),
+	}
+}
+
+// firstTime returns true the first time it's called for a secret with a
+// given kind and value; the filename and context don't matter. It always
+// returns true when called on a nil *seenSecrets.
+func (s *seenSecrets) firstTime(secret *jsluice.Secret) bool {
+	if s == nil {
+		return true
+	}
+
+	// Maps are marshalled with their keys sorted,
+	// so the same data always gives the same key
+	value, err := json.Marshal(secret.Data)
+	if err != nil {
+		return true
+	}
+	key := secret.Kind + "\x00" + string(value)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.seen[key] {
+		return false
+	}
+	s.seen[key] = true
+	return true
+}
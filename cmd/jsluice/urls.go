@@ -4,25 +4,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
 
 	"github.com/BishopFox/jsluice"
 )
 
 func extractURLs(opts options, filename string, source []byte, output chan string, errs chan error) {
+	extract(opts, filename, source, true, false, output, errs)
+}
 
-	var resolveURL *url.URL
-	var err error
-	if opts.resolvePaths != "" {
-		resolveURL, err = url.Parse(opts.resolvePaths)
-		if err != nil {
-			errs <- err
-			return
-		}
+// findURLs returns the URLs found by an analyzer that should be output for
+// a file, after the URL options (e.g. --url-filter and --unique) have been
+// applied, so that other modes can use the same analyzer for more than one
+// kind of result
+func findURLs(opts options, filename string, analzyer *jsluice.Analyzer) ([]*jsluice.URL, error) {
+	out := make([]*jsluice.URL, 0)
+
+	resolveURL, err := baseURL(opts, filename)
+	if err != nil {
+		return out, err
 	}
 
 	seen := make(map[string]any, 0)
 
-	analzyer := jsluice.NewAnalyzer(source)
 	for _, m := range analzyer.GetURLs() {
 		if opts.ignoreStrings && m.Type == "stringLiteral" {
 			continue
@@ -35,6 +39,16 @@ func extractURLs(opts options, filename string, source []byte, output chan strin
 			m.Source = ""
 		}
 
+		if !opts.includeRawURL {
+			m.RawURL = ""
+		}
+
+		// positions are always needed for SARIF output and for
+		// sorting, and are removed later if they're not wanted
+		if !opts.positions && opts.sarif == nil && opts.sorted == nil {
+			m.Position = nil
+		}
+
 		if resolveURL != nil {
 			parsed, err := url.Parse(m.URL)
 			if err == nil {
@@ -42,16 +56,99 @@ func extractURLs(opts options, filename string, source []byte, output chan strin
 			}
 		}
 
+		if opts.urlFilter != nil && !opts.urlFilter.MatchString(m.URL) {
+			continue
+		}
+
+		if opts.urlExclude != nil && opts.urlExclude.MatchString(m.URL) {
+			continue
+		}
+
 		if _, exists := seen[m.URL]; opts.unique && exists {
 			continue
 		}
 		seen[m.URL] = struct{}{}
 
-		j, err := json.Marshal(m)
+		out = append(out, m)
+	}
+
+	return out, nil
+}
+
+// writeURLs outputs URLs, or hands them to whichever collector is in use,
+// and returns how many there were
+func writeURLs(opts options, urls []*jsluice.URL, output chan string, errs chan error) int {
+	for _, m := range urls {
+		if opts.params != nil {
+			opts.params.addURL(m)
+			continue
+		}
+
+		if opts.sarif != nil {
+			opts.sarif.addURL(m)
+			continue
+		}
+
+		if opts.sorted != nil {
+			opts.sorted.addURL(m)
+			continue
+		}
+
+		j, err := marshal(opts, m)
 		if err != nil {
 			errs <- err
 			continue
 		}
 		output <- fmt.Sprintf("%s", j)
 	}
+
+	return len(urls)
+}
+
+// baseURL returns the URL that relative paths found in a file should be
+// resolved against, or nil if they shouldn't be resolved. A base URL for
+// the file from --resolve-from-file is used first. Responses in WARC and
+// HAR files are then resolved against their own URLs, because that's
+// where they were fetched from, and the base URL from --resolve-paths is
+// only used when a response's URL can't be, and for other files.
+func baseURL(opts options, filename string) (*url.URL, error) {
+	if base, exists := opts.resolveBases[filename]; exists {
+		return url.Parse(base)
+	}
+
+	if (opts.warc || opts.har) && filename != "" {
+		if u, err := url.Parse(filename); err == nil && u.IsAbs() {
+			return u, nil
+		}
+	}
+
+	if opts.resolvePaths != "" {
+		return url.Parse(opts.resolvePaths)
+	}
+
+	return nil, nil
+}
+
+// loadResolveBases reads a JSON file containing an object that maps
+// filenames to base URLs; e.g. {"main.js": "https://example.com/js/"}
+func loadResolveBases(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bases := make(map[string]string)
+	err = json.NewDecoder(f).Decode(&bases)
+	if err != nil {
+		return nil, err
+	}
+
+	for file, base := range bases {
+		if _, err := url.Parse(base); err != nil {
+			return nil, fmt.Errorf("invalid base URL for %s: %w", file, err)
+		}
+	}
+
+	return bases, nil
 }
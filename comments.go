@@ -0,0 +1,34 @@
+package jsluice
+
+import (
+	"strings"
+)
+
+// commentURLs finds anything that looks like a URL in a comment node.
+// Comments are free text, so each whitespace-separated word is checked
+// with MaybeURL after any surrounding punctuation has been removed.
+func commentURLs(n *Node) []*URL {
+	out := make([]*URL, 0)
+
+	text := n.Content()
+	text = strings.TrimPrefix(text, "//")
+	text = strings.TrimPrefix(text, "/*")
+	text = strings.TrimSuffix(text, "*/")
+
+	for _, word := range strings.Fields(text) {
+		word = strings.Trim(word, "\"'`()[]<>{},;")
+		word = strings.TrimRight(word, ".:")
+
+		if len(word) < 2 || !MaybeURL(word) {
+			continue
+		}
+
+		out = append(out, &URL{
+			URL:    word,
+			Type:   "comment",
+			Source: n.Content(),
+		})
+	}
+
+	return out
+}
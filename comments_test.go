@@ -0,0 +1,42 @@
+package jsluice
+
+import (
+	"testing"
+)
+
+func TestCommentURLs(t *testing.T) {
+	a := NewAnalyzer([]byte(`
+		// TODO: move this to https://internal.example.com/api/v2.
+		/*
+		 * Endpoints: /api/users, (/api/groups?id=1)
+		 * Not a path: foo/bar
+		 */
+		var x = 1
+	`))
+
+	if len(a.GetURLs()) != 0 {
+		t.Errorf("Expected no URLs when IncludeComments is not set")
+	}
+
+	a.IncludeComments = true
+	urls := a.GetURLs()
+
+	expected := []string{
+		"https://internal.example.com/api/v2",
+		"/api/users",
+		"/api/groups?id=1",
+	}
+
+	if len(urls) != len(expected) {
+		t.Fatalf("want %d URLs from comments; have %d", len(expected), len(urls))
+	}
+
+	for i, u := range urls {
+		if u.URL != expected[i] {
+			t.Errorf("want %s for URL %d; have %s", expected[i], i, u.URL)
+		}
+		if u.Type != "comment" {
+			t.Errorf("want type comment for URL %d; have %s", i, u.Type)
+		}
+	}
+}
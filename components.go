@@ -0,0 +1,108 @@
+package jsluice
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Component frameworks like Vue and Svelte keep a component's markup,
+// scripts, and styles together in a single file. The files look a bit
+// like HTML, but parsing them as HTML tends to mix markup in with the
+// scripts, so they get their own, much simpler, extractor instead.
+
+var (
+	componentBlockOpen     = regexp.MustCompile(`(?i)<(template|script|style)\b[^>]*>`)
+	componentTemplateTag   = regexp.MustCompile(`(?i)<(/?)template\b[^>]*>`)
+	componentScriptClose   = regexp.MustCompile(`(?i)</script\s*>`)
+	componentStyleClose    = regexp.MustCompile(`(?i)</style\s*>`)
+	componentFirstBlockTag = regexp.MustCompile(`(?is)^\s*(<!--.*?-->\s*)*<(template|script|style)[\s>]`)
+)
+
+// isProbablyComponent returns true for source that looks like a Vue
+// or Svelte single-file component; i.e. the first thing in the file
+// (ignoring whitespace and comments) is a <template>, <script>, or
+// <style> block.
+func isProbablyComponent(source []byte) bool {
+	return componentFirstBlockTag.Match(source)
+}
+
+// extractComponentBlocks extracts the contents of the <script> blocks
+// from a single-file component, along with the contents of any <style>
+// blocks. That covers Vue's <script> and <script setup>, and Svelte's
+// <script> and <script context="module">. The contents of <template>
+// blocks are skipped entirely, as is any other markup, so that it
+// doesn't end up being parsed as JavaScript.
+func extractComponentBlocks(source []byte) ([]byte, []byte) {
+	var scripts, styles []byte
+
+	pos := 0
+	for pos < len(source) {
+		loc := componentBlockOpen.FindSubmatchIndex(source[pos:])
+		if loc == nil {
+			break
+		}
+
+		// Skip over any comments that come before the next block,
+		// just in case they contain something that looks like a tag
+		if c := bytes.Index(source[pos:pos+loc[0]], []byte("<!--")); c != -1 {
+			end := bytes.Index(source[pos+c:], []byte("-->"))
+			if end == -1 {
+				break
+			}
+			pos += c + end + len("-->")
+			continue
+		}
+
+		tag := strings.ToLower(string(source[pos+loc[2] : pos+loc[3]]))
+		start := pos + loc[1]
+
+		switch tag {
+		case "template":
+			// Templates can contain other templates (e.g. for slots),
+			// so we need to find the matching closing tag
+			pos = skipTemplate(source, start)
+
+		case "style":
+			end := componentStyleClose.FindIndex(source[start:])
+			if end == nil {
+				return scripts, append(styles, source[start:]...)
+			}
+			styles = append(styles, source[start:start+end[0]]...)
+			styles = append(styles, '\n')
+			pos = start + end[1]
+
+		case "script":
+			end := componentScriptClose.FindIndex(source[start:])
+			if end == nil {
+				// Unterminated script block, so just take everything that's left
+				return append(scripts, source[start:]...), styles
+			}
+			scripts = append(scripts, source[start:start+end[0]]...)
+			scripts = append(scripts, '\n')
+			pos = start + end[1]
+		}
+	}
+
+	return scripts, styles
+}
+
+// skipTemplate returns the position in the source just after the
+// </template> tag that closes the template block starting at pos.
+func skipTemplate(source []byte, pos int) int {
+	depth := 1
+	for depth > 0 {
+		loc := componentTemplateTag.FindSubmatchIndex(source[pos:])
+		if loc == nil {
+			return len(source)
+		}
+
+		if loc[3] > loc[2] {
+			depth--
+		} else {
+			depth++
+		}
+		pos += loc[1]
+	}
+	return pos
+}
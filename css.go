@@ -0,0 +1,48 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	cssURLToken = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+	cssImport   = regexp.MustCompile(`(?i)@import\s+(?:"([^"]*)"|'([^']*)')`)
+)
+
+// cssURLs finds the URLs referenced in some CSS source; i.e. any
+// url(...) tokens, and @import rules that use a plain string
+func cssURLs(css []byte) []*URL {
+	out := make([]*URL, 0)
+	if len(css) == 0 {
+		return out
+	}
+
+	for _, re := range []*regexp.Regexp{cssURLToken, cssImport} {
+		for _, m := range re.FindAllSubmatch(css, -1) {
+			// only one of the quoted or unquoted forms will have matched,
+			// so we just take the first non-empty group
+			var val string
+			for _, g := range m[1:] {
+				if len(g) > 0 {
+					val = string(g)
+					break
+				}
+			}
+
+			val = strings.TrimSpace(val)
+			if val == "" {
+				continue
+			}
+
+			out = append(out, &URL{
+				URL:    val,
+				Method: "GET",
+				Type:   "cssUrl",
+				Source: string(m[0]),
+			})
+		}
+	}
+
+	return out
+}
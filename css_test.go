@@ -0,0 +1,75 @@
+package jsluice
+
+import (
+	"testing"
+)
+
+func TestCSSURLs(t *testing.T) {
+	css := []byte(`
+		@import "/css/base.css";
+		@import url('/css/print.css') print;
+		.logo { background: url(/img/logo.png) no-repeat; }
+		.bg { background-image: url( "https://cdn.example.com/bg.jpg" ); }
+		.empty { background: url(); }
+	`)
+
+	expected := []string{
+		"/css/print.css",
+		"/img/logo.png",
+		"https://cdn.example.com/bg.jpg",
+		"/css/base.css",
+	}
+
+	urls := cssURLs(css)
+
+	if len(urls) != len(expected) {
+		t.Fatalf("want %d URLs from cssURLs(); have %d", len(expected), len(urls))
+	}
+
+	for i, u := range urls {
+		if u.URL != expected[i] {
+			t.Errorf("want %s for URL %d; have %s", expected[i], i, u.URL)
+		}
+		if u.Type != "cssUrl" {
+			t.Errorf("want cssUrl type for URL %d; have %s", i, u.Type)
+		}
+	}
+}
+
+func TestAnalyzerHTMLStyles(t *testing.T) {
+	a := NewAnalyzer([]byte(`
+		<html>
+			<head>
+				<style>body { background: url("/img/body.png") }</style>
+				<script>var loaded = true</script>
+			</head>
+			<body>
+				<div style="background: url('/img/div.png')"></div>
+				<img src="data:image/png;base64,AAAA">
+				<p style="background: url(data:image/png;base64,AAAA)"></p>
+			</body>
+		</html>
+	`))
+
+	a.IncludeStyles = true
+
+	urls := a.GetURLs()
+
+	if len(urls) != 2 {
+		t.Fatalf("want 2 URLs from HTML styles; have %d", len(urls))
+	}
+
+	if urls[0].URL != "/img/body.png" || urls[1].URL != "/img/div.png" {
+		t.Errorf("want /img/body.png and /img/div.png; have %s and %s", urls[0].URL, urls[1].URL)
+	}
+}
+
+func TestAnalyzerForceCSS(t *testing.T) {
+	a := NewAnalyzerWithHint([]byte(`.a { background: url("/a.png") }`), ForceCSS)
+
+	urls := a.GetURLs()
+
+	if len(urls) != 1 || urls[0].URL != "/a.png" {
+		t.Errorf("want exactly /a.png from CSS source; have %d URLs", len(urls))
+	}
+}
@@ -0,0 +1,74 @@
+package jsluice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// debugf records a reason for a matcher's decision about a node. The
+// reasons are written to the Analyzer's Debug writer along with the
+// outcome once the matcher has finished.
+func (a *Analyzer) debugf(format string, args ...any) {
+	if a == nil || a.Debug == nil {
+		return
+	}
+	a.debugReasons = append(a.debugReasons, fmt.Sprintf(format, args...))
+}
+
+// debugf records a reason for a matcher's decision about the Node,
+// if the Node belongs to an Analyzer that has debugging enabled
+func (n *Node) debugf(format string, args ...any) {
+	if n == nil {
+		return
+	}
+	n.analyzer.debugf(format, args...)
+}
+
+// logDecision writes a line describing the outcome of running a matcher
+// against a node to the Debug writer, along with any reasons that were
+// recorded. Nodes that a matcher ignored without giving a reason are not
+// logged, because that would include almost every node in the tree.
+func (a *Analyzer) logDecision(matcher string, n *Node, match *URL, kept bool) {
+	if a.Debug == nil {
+		return
+	}
+
+	reasons := a.debugReasons
+	a.debugReasons = nil
+
+	if match == nil && len(reasons) == 0 {
+		return
+	}
+
+	outcome := "no match"
+	if match != nil && kept {
+		outcome = fmt.Sprintf("matched %s", match.URL)
+	} else if match != nil {
+		outcome = fmt.Sprintf("discarded %s", match.URL)
+	}
+
+	line := fmt.Sprintf("[%s] %s", matcher, outcome)
+	if n != nil {
+		line = fmt.Sprintf("[%s] %s %s: %s", matcher, n.Type(), debugSnippet(n.Content()), outcome)
+	}
+
+	if len(reasons) > 0 {
+		line += fmt.Sprintf(" (%s)", strings.Join(reasons, "; "))
+	}
+
+	fmt.Fprintln(a.Debug, line)
+}
+
+// debugSnippet returns a short, single-line version
+// of some source code for use in debug output
+func debugSnippet(source string) string {
+	if i := strings.IndexAny(source, "\r\n"); i != -1 {
+		source = source[:i] + "..."
+	}
+
+	if len(source) > 60 {
+		source = source[:60] + "..."
+	}
+
+	return fmt.Sprintf("%q", source)
+}
@@ -0,0 +1,33 @@
+package jsluice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzerDebug(t *testing.T) {
+	a := NewAnalyzer([]byte(`
+		fetch(someUrl)
+		fetch("/api/v1")
+		location.href = "data:text/html,hi"
+	`))
+
+	buf := &bytes.Buffer{}
+	a.Debug = buf
+	a.GetURLs()
+
+	out := buf.String()
+
+	expected := []string{
+		`[fetch] call_expression "fetch(someUrl)": no match (first argument is not a string)`,
+		`[fetch] call_expression "fetch(\"/api/v1\")": matched /api/v1`,
+		`discarded data:text/html,hi (unwanted scheme)`,
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("want debug output to contain %s; have:\n%s", e, out)
+		}
+	}
+}
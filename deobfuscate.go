@@ -0,0 +1,837 @@
+package jsluice
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+const (
+	// maxDeobfuscateSteps is the maximum number of nodes that will be
+	// evaluated while deobfuscating a single source file, so that
+	// deobfuscation can't hang on adversarial input
+	maxDeobfuscateSteps = 1000000
+
+	// maxDeobfuscatedLength is the maximum length of any string or
+	// array produced while deobfuscating
+	maxDeobfuscatedLength = 1 << 16
+)
+
+// deobfuscate replaces the parse tree with one for a version of the
+// source where obfuscated expressions that evaluate to strings have
+// been replaced with string literals. Only a small, side-effect free
+// subset of JavaScript is evaluated: the primitives used by JSFuck
+// (e.g. ![], +[], (![]+[])[+[]]), String.fromCharCode in its various
+// forms, and simple array and string methods like split, reverse, join
+// and map. The original tree is kept if there is nothing to replace.
+func (a *Analyzer) deobfuscate() {
+	a.deobfuscated = true
+
+	root := a.rootNode
+	if root.node == nil {
+		return
+	}
+	source := root.source
+
+	e := newEvaluator()
+	buf := &bytes.Buffer{}
+	last := uint32(0)
+	replaced := false
+
+	root.walk(func(sn *sitter.Node, depth int) bool {
+		if !evaluableTypes[sn.Type()] {
+			return true
+		}
+
+		n := root.wrap(sn)
+		v, ok := e.eval(n, nil)
+		if !ok || v.kind != jsString {
+			return true
+		}
+
+		// Plain strings and concatenations of them aren't obfuscated
+		if _, literal := literalParts(n); literal {
+			return false
+		}
+
+		buf.Write(source[last:sn.StartByte()])
+		buf.WriteString(jsQuote(v.str))
+		last = sn.EndByte()
+		replaced = true
+
+		return false
+	})
+
+	if !replaced {
+		return
+	}
+	buf.Write(source[last:])
+
+	deobfuscated := buf.Bytes()
+	a.rootNode = a.newRoot(a.parse(nil, deobfuscated), deobfuscated)
+	a.tree = nil
+}
+
+// evaluableTypes are the types of node that deobfuscate will try to
+// replace with the strings they evaluate to. Parenthesized expressions
+// aren't included because they're also used for the conditions in if
+// statements etc, where replacing them would be a syntax error.
+var evaluableTypes = map[string]bool{
+	"binary_expression":    true,
+	"call_expression":      true,
+	"member_expression":    true,
+	"subscript_expression": true,
+	"unary_expression":     true,
+}
+
+type jsKind int
+
+const (
+	jsUndefined jsKind = iota
+	jsNull
+	jsBoolean
+	jsNumber
+	jsString
+	jsArray
+
+	// the String constructor; e.g. String, or ""["constructor"]
+	jsStringConstructor
+
+	// String.fromCharCode
+	jsFromCharCode
+
+	// a function with a single parameter that returns an expression
+	jsFunction
+)
+
+// jsValue is the result of evaluating an expression
+type jsValue struct {
+	kind jsKind
+	b    bool
+	num  float64
+	str  string
+	arr  []jsValue
+
+	// for jsFunction values
+	param string
+	body  *Node
+}
+
+// evaluator evaluates expressions for deobfuscate, keeping track of
+// how much work it has done so that it can give up
+type evaluator struct {
+	steps int
+
+	// values of expressions that have been evaluated outside of any
+	// function, so that they aren't evaluated again when deobfuscate
+	// looks at the expressions nested inside a larger one. Failures
+	// aren't cached; most of them are cheap to find again.
+	cache map[*sitter.Node]jsValue
+}
+
+func newEvaluator() *evaluator {
+	return &evaluator{
+		cache: make(map[*sitter.Node]jsValue),
+	}
+}
+
+// eval returns the value of the expression n, and false if it can't be
+// evaluated. env holds the values of any function parameters in scope.
+func (e *evaluator) eval(n *Node, env map[string]jsValue) (jsValue, bool) {
+	if !n.IsValid() {
+		return jsValue{}, false
+	}
+
+	cacheable := env == nil && n.NamedChildCount() > 0
+	if cacheable {
+		if v, exists := e.cache[n.node]; exists {
+			return v, true
+		}
+	}
+
+	e.steps++
+	if e.steps > maxDeobfuscateSteps {
+		return jsValue{}, false
+	}
+
+	v, ok := e.evalNode(n, env)
+
+	// results that are too big are treated as failures
+	if ok && (len(v.str) > maxDeobfuscatedLength || len(v.arr) > maxDeobfuscatedLength) {
+		return jsValue{}, false
+	}
+
+	if ok && cacheable {
+		e.cache[n.node] = v
+	}
+	return v, ok
+}
+
+func (e *evaluator) evalNode(n *Node, env map[string]jsValue) (jsValue, bool) {
+	switch n.Type() {
+	case "string":
+		return jsValue{kind: jsString, str: n.DecodedString()}, true
+
+	case "number":
+		num := stringToNumber(strings.ReplaceAll(n.Content(), "_", ""))
+		return jsValue{kind: jsNumber, num: num}, true
+
+	case "true", "false":
+		return jsValue{kind: jsBoolean, b: n.Type() == "true"}, true
+
+	case "undefined":
+		return jsValue{kind: jsUndefined}, true
+
+	case "null":
+		return jsValue{kind: jsNull}, true
+
+	case "identifier":
+		name := n.Content()
+		if v, exists := env[name]; exists {
+			return v, true
+		}
+		switch name {
+		case "String":
+			return jsValue{kind: jsStringConstructor}, true
+		case "NaN":
+			return jsValue{kind: jsNumber, num: math.NaN()}, true
+		case "Infinity":
+			return jsValue{kind: jsNumber, num: math.Inf(1)}, true
+		}
+		return jsValue{}, false
+
+	case "parenthesized_expression":
+		if n.NamedChildCount() != 1 {
+			return jsValue{}, false
+		}
+		return e.eval(n.NamedChild(0), env)
+
+	case "array":
+		arr := make([]jsValue, 0, n.NamedChildCount())
+		for _, child := range n.NamedChildren() {
+			v, ok := e.eval(child, env)
+			if !ok {
+				return jsValue{}, false
+			}
+			arr = append(arr, v)
+		}
+		return jsValue{kind: jsArray, arr: arr}, true
+
+	case "unary_expression":
+		v, ok := e.eval(n.ChildByFieldName("argument"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+
+		switch n.ChildByFieldName("operator").Content() {
+		case "!":
+			b, ok := v.toBoolean()
+			return jsValue{kind: jsBoolean, b: !b}, ok
+		case "+":
+			num, ok := v.toNumber()
+			return jsValue{kind: jsNumber, num: num}, ok
+		case "-":
+			num, ok := v.toNumber()
+			return jsValue{kind: jsNumber, num: -num}, ok
+		}
+		return jsValue{}, false
+
+	case "binary_expression":
+		left, ok := e.eval(n.ChildByFieldName("left"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		right, ok := e.eval(n.ChildByFieldName("right"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		return binaryOp(n.ChildByFieldName("operator").Content(), left, right)
+
+	case "subscript_expression":
+		object, ok := e.eval(n.ChildByFieldName("object"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		index, ok := e.eval(n.ChildByFieldName("index"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		key, ok := index.propertyKey()
+		if !ok {
+			return jsValue{}, false
+		}
+		return object.property(key)
+
+	case "member_expression":
+		object, ok := e.eval(n.ChildByFieldName("object"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		return object.property(n.ChildByFieldName("property").Content())
+
+	case "arrow_function", "function":
+		return functionValue(n)
+
+	case "call_expression":
+		return e.evalCall(n, env)
+	}
+
+	return jsValue{}, false
+}
+
+// evalCall evaluates calls to String.fromCharCode, and to the
+// handful of string and array methods that obfuscators commonly use
+func (e *evaluator) evalCall(n *Node, env map[string]jsValue) (jsValue, bool) {
+	callee := n.ChildByFieldName("function")
+
+	var object jsValue
+	var method string
+	var ok bool
+	direct := false
+
+	// The callee is evaluated first, because most calls in the
+	// source are to something that can't be evaluated
+	switch callee.Type() {
+	case "member_expression":
+		object, ok = e.eval(callee.ChildByFieldName("object"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		method = callee.ChildByFieldName("property").Content()
+
+	case "subscript_expression":
+		object, ok = e.eval(callee.ChildByFieldName("object"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		index, ok := e.eval(callee.ChildByFieldName("index"), env)
+		if !ok {
+			return jsValue{}, false
+		}
+		method, ok = index.propertyKey()
+		if !ok {
+			return jsValue{}, false
+		}
+
+	default:
+		object, ok = e.eval(callee, env)
+		if !ok || object.kind != jsFromCharCode {
+			return jsValue{}, false
+		}
+		direct = true
+	}
+
+	args, ok := e.evalArgs(n.ChildByFieldName("arguments"), env)
+	if !ok {
+		return jsValue{}, false
+	}
+
+	if direct {
+		return fromCharCode(args)
+	}
+
+	switch object.kind {
+	case jsStringConstructor:
+		if method == "fromCharCode" {
+			return fromCharCode(args)
+		}
+
+	case jsFromCharCode:
+		switch method {
+		case "call":
+			if len(args) == 0 {
+				return fromCharCode(args)
+			}
+			return fromCharCode(args[1:])
+		case "apply":
+			if len(args) < 2 {
+				return fromCharCode(nil)
+			}
+			if args[1].kind != jsArray {
+				return jsValue{}, false
+			}
+			return fromCharCode(args[1].arr)
+		}
+
+	case jsString:
+		return stringMethod(object.str, method, args)
+
+	case jsArray:
+		if method == "map" {
+			return e.mapArray(object.arr, args)
+		}
+		return arrayMethod(object.arr, method, args)
+	}
+
+	return jsValue{}, false
+}
+
+// evalArgs evaluates the arguments to a call, expanding any spread
+// elements (e.g. ...[104, 105]) into separate arguments
+func (e *evaluator) evalArgs(n *Node, env map[string]jsValue) ([]jsValue, bool) {
+	out := make([]jsValue, 0)
+	if !n.IsValid() {
+		return out, false
+	}
+
+	for _, arg := range n.NamedChildren() {
+		if arg.Type() == "spread_element" {
+			v, ok := e.eval(arg.NamedChild(0), env)
+			if !ok || v.kind != jsArray {
+				return nil, false
+			}
+			out = append(out, v.arr...)
+			continue
+		}
+
+		v, ok := e.eval(arg, env)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, v)
+	}
+	return out, true
+}
+
+// mapArray calls a single-parameter function for each item in an array
+func (e *evaluator) mapArray(arr []jsValue, args []jsValue) (jsValue, bool) {
+	if len(args) != 1 || args[0].kind != jsFunction {
+		return jsValue{}, false
+	}
+	fn := args[0]
+
+	out := make([]jsValue, 0, len(arr))
+	for _, item := range arr {
+		v, ok := e.eval(fn.body, map[string]jsValue{fn.param: item})
+		if !ok {
+			return jsValue{}, false
+		}
+		out = append(out, v)
+	}
+	return jsValue{kind: jsArray, arr: out}, true
+}
+
+// functionValue returns a jsFunction for functions with a single parameter
+// that either have an expression body, or a body with a single return
+// statement; e.g. c => String.fromCharCode(c)
+func functionValue(n *Node) (jsValue, bool) {
+	var param string
+	if p := n.ChildByFieldName("parameter"); p.IsValid() {
+		param = p.Content()
+	} else {
+		params := n.ChildByFieldName("parameters")
+		if !params.IsValid() || params.NamedChildCount() != 1 ||
+			params.NamedChild(0).Type() != "identifier" {
+			return jsValue{}, false
+		}
+		param = params.NamedChild(0).Content()
+	}
+
+	body := n.ChildByFieldName("body")
+	if body.Type() == "statement_block" {
+		if body.NamedChildCount() != 1 || body.NamedChild(0).Type() != "return_statement" {
+			return jsValue{}, false
+		}
+		body = body.NamedChild(0).NamedChild(0)
+	}
+
+	if !body.IsValid() {
+		return jsValue{}, false
+	}
+	return jsValue{kind: jsFunction, param: param, body: body}, true
+}
+
+// fromCharCode implements String.fromCharCode
+func fromCharCode(args []jsValue) (jsValue, bool) {
+	units := make([]uint16, 0, len(args))
+	for _, arg := range args {
+		num, ok := arg.toNumber()
+		if !ok {
+			return jsValue{}, false
+		}
+		units = append(units, toUint16(num))
+	}
+	return jsValue{kind: jsString, str: string(utf16.Decode(units))}, true
+}
+
+func stringMethod(s string, method string, args []jsValue) (jsValue, bool) {
+	switch method {
+	case "split":
+		if len(args) != 1 || args[0].kind != jsString {
+			return jsValue{}, false
+		}
+		// count the parts first so that huge arrays aren't built
+		sep := args[0].str
+		n := len(s)
+		if sep != "" {
+			n = strings.Count(s, sep) + 1
+		}
+		if n > maxDeobfuscatedLength {
+			return jsValue{}, false
+		}
+
+		parts := strings.Split(s, sep)
+		arr := make([]jsValue, 0, len(parts))
+		for _, p := range parts {
+			arr = append(arr, jsValue{kind: jsString, str: p})
+		}
+		return jsValue{kind: jsArray, arr: arr}, true
+
+	case "charAt", "charCodeAt":
+		i := 0.0
+		if len(args) > 0 {
+			var ok bool
+			i, ok = args[0].toNumber()
+			if !ok {
+				return jsValue{}, false
+			}
+		}
+		units := utf16.Encode([]rune(s))
+		if i < 0 || int(i) >= len(units) {
+			if method == "charAt" {
+				return jsValue{kind: jsString}, true
+			}
+			return jsValue{kind: jsNumber, num: math.NaN()}, true
+		}
+		if method == "charAt" {
+			return jsValue{kind: jsString, str: string(utf16.Decode(units[int(i) : int(i)+1]))}, true
+		}
+		return jsValue{kind: jsNumber, num: float64(units[int(i)])}, true
+
+	case "concat":
+		parts := []string{s}
+		length := len(s)
+		for _, arg := range args {
+			str, ok := arg.toString()
+			if !ok {
+				return jsValue{}, false
+			}
+			parts = append(parts, str)
+			length += len(str)
+		}
+		if length > maxDeobfuscatedLength {
+			return jsValue{}, false
+		}
+		return jsValue{kind: jsString, str: strings.Join(parts, "")}, true
+
+	case "toLowerCase":
+		return jsValue{kind: jsString, str: strings.ToLower(s)}, true
+
+	case "toUpperCase":
+		return jsValue{kind: jsString, str: strings.ToUpper(s)}, true
+	}
+
+	return jsValue{}, false
+}
+
+func arrayMethod(arr []jsValue, method string, args []jsValue) (jsValue, bool) {
+	switch method {
+	case "join":
+		sep := ","
+		if len(args) > 0 && args[0].kind != jsUndefined {
+			var ok bool
+			sep, ok = args[0].toString()
+			if !ok {
+				return jsValue{}, false
+			}
+		}
+		return joinArray(arr, sep)
+
+	case "reverse":
+		out := make([]jsValue, len(arr))
+		for i, v := range arr {
+			out[len(arr)-1-i] = v
+		}
+		return jsValue{kind: jsArray, arr: out}, true
+	}
+
+	return jsValue{}, false
+}
+
+func joinArray(arr []jsValue, sep string) (jsValue, bool) {
+	parts := make([]string, 0, len(arr))
+	for _, v := range arr {
+		if v.kind == jsUndefined || v.kind == jsNull {
+			parts = append(parts, "")
+			continue
+		}
+		s, ok := v.toString()
+		if !ok {
+			return jsValue{}, false
+		}
+		parts = append(parts, s)
+	}
+
+	// check the length of the result before building it, because
+	// joining big arrays with a big separator can use a lot of memory
+	length := len(sep) * (len(parts) - 1)
+	for _, p := range parts {
+		length += len(p)
+	}
+	if length > maxDeobfuscatedLength {
+		return jsValue{}, false
+	}
+	return jsValue{kind: jsString, str: strings.Join(parts, sep)}, true
+}
+
+// binaryOp implements + and a few numeric operators
+func binaryOp(op string, left, right jsValue) (jsValue, bool) {
+	if op == "+" {
+		// arrays are converted to strings when they're added to anything
+		if left.kind == jsString || right.kind == jsString ||
+			left.kind == jsArray || right.kind == jsArray {
+			l, ok := left.toString()
+			if !ok {
+				return jsValue{}, false
+			}
+			r, ok := right.toString()
+			if !ok {
+				return jsValue{}, false
+			}
+			return jsValue{kind: jsString, str: l + r}, true
+		}
+	}
+
+	l, ok := left.toNumber()
+	if !ok {
+		return jsValue{}, false
+	}
+	r, ok := right.toNumber()
+	if !ok {
+		return jsValue{}, false
+	}
+
+	switch op {
+	case "+":
+		return jsValue{kind: jsNumber, num: l + r}, true
+	case "-":
+		return jsValue{kind: jsNumber, num: l - r}, true
+	case "*":
+		return jsValue{kind: jsNumber, num: l * r}, true
+	case "/":
+		return jsValue{kind: jsNumber, num: l / r}, true
+	case "%":
+		return jsValue{kind: jsNumber, num: math.Mod(l, r)}, true
+	case "^":
+		return jsValue{kind: jsNumber, num: float64(toInt32(l) ^ toInt32(r))}, true
+	}
+
+	return jsValue{}, false
+}
+
+// propertyKey returns the property name that a value
+// refers to when it's used as a subscript index
+func (v jsValue) propertyKey() (string, bool) {
+	return v.toString()
+}
+
+// property returns the value of a property. Only indexes, length, and
+// the properties needed to get to String.fromCharCode are supported.
+func (v jsValue) property(key string) (jsValue, bool) {
+	if key == "" {
+		return jsValue{kind: jsUndefined}, true
+	}
+
+	switch v.kind {
+	case jsString:
+		switch key {
+		case "length":
+			return jsValue{kind: jsNumber, num: float64(len(utf16.Encode([]rune(v.str))))}, true
+		case "constructor":
+			return jsValue{kind: jsStringConstructor}, true
+		}
+
+		if i, ok := arrayIndex(key); ok {
+			units := utf16.Encode([]rune(v.str))
+			if i >= len(units) {
+				return jsValue{kind: jsUndefined}, true
+			}
+			return jsValue{kind: jsString, str: string(utf16.Decode(units[i : i+1]))}, true
+		}
+
+	case jsArray:
+		if key == "length" {
+			return jsValue{kind: jsNumber, num: float64(len(v.arr))}, true
+		}
+
+		if i, ok := arrayIndex(key); ok {
+			if i >= len(v.arr) {
+				return jsValue{kind: jsUndefined}, true
+			}
+			return v.arr[i], true
+		}
+
+	case jsStringConstructor:
+		if key == "fromCharCode" {
+			return jsValue{kind: jsFromCharCode}, true
+		}
+	}
+
+	return jsValue{}, false
+}
+
+func (v jsValue) toBoolean() (bool, bool) {
+	switch v.kind {
+	case jsUndefined, jsNull:
+		return false, true
+	case jsBoolean:
+		return v.b, true
+	case jsNumber:
+		return v.num != 0 && !math.IsNaN(v.num), true
+	case jsString:
+		return v.str != "", true
+	case jsArray:
+		return true, true
+	}
+	return false, false
+}
+
+func (v jsValue) toNumber() (float64, bool) {
+	switch v.kind {
+	case jsUndefined:
+		return math.NaN(), true
+	case jsNull:
+		return 0, true
+	case jsBoolean:
+		if v.b {
+			return 1, true
+		}
+		return 0, true
+	case jsNumber:
+		return v.num, true
+	case jsString:
+		return stringToNumber(v.str), true
+	case jsArray:
+		s, ok := v.toString()
+		if !ok {
+			return 0, false
+		}
+		return stringToNumber(s), true
+	}
+	return 0, false
+}
+
+func (v jsValue) toString() (string, bool) {
+	switch v.kind {
+	case jsUndefined:
+		return "undefined", true
+	case jsNull:
+		return "null", true
+	case jsBoolean:
+		return strconv.FormatBool(v.b), true
+	case jsNumber:
+		return numberToString(v.num), true
+	case jsString:
+		return v.str, true
+	case jsArray:
+		j, ok := joinArray(v.arr, ",")
+		return j.str, ok
+	}
+	return "", false
+}
+
+var decimalNumber = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// stringToNumber converts a string to a number the way JavaScript does
+func stringToNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return 0
+	case "Infinity", "+Infinity":
+		return math.Inf(1)
+	case "-Infinity":
+		return math.Inf(-1)
+	}
+
+	if decimalNumber.MatchString(s) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil {
+			return f
+		}
+	}
+
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "0x") || strings.HasPrefix(lower, "0o") || strings.HasPrefix(lower, "0b") {
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err == nil {
+			return float64(i)
+		}
+	}
+
+	return math.NaN()
+}
+
+// numberToString converts a number to a string the way JavaScript
+// does for the values that are likely to be seen in obfuscated code
+func numberToString(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == math.Trunc(f) && math.Abs(f) < 1e21:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// arrayIndex returns the integer index for a canonical
+// non-negative integer property name; e.g. "0" but not "00"
+func arrayIndex(key string) (int, bool) {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || strconv.Itoa(i) != key {
+		return 0, false
+	}
+	return i, true
+}
+
+func toInt32(f float64) int32 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int32(uint32(int64(math.Trunc(math.Mod(f, 1<<32)))))
+}
+
+func toUint16(f float64) uint16 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return uint16(int64(math.Trunc(math.Mod(f, 1<<16))))
+}
+
+// jsQuote returns a double-quoted JavaScript string literal for s
+func jsQuote(s string) string {
+	b := &strings.Builder{}
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f || r == 0x2028 || r == 0x2029:
+			fmt.Fprintf(b, `\u%04x`, r)
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(b, `\u%04x\u%04x`, r1, r2)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package jsluice
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDeobfuscate(t *testing.T) {
+	cases := []struct {
+		JS       string
+		Expected string
+	}{
+		{`fetch(String.fromCharCode(47, 97, 112, 105))`, "/api"},
+		{`fetch(String["fromCharCode"](0x2f, 0x78))`, "/x"},
+		{`fetch(""["constructor"]["fromCharCode"](47, 121))`, "/y"},
+		{`fetch(String.fromCharCode.apply(null, [47, 97]))`, "/a"},
+		{`fetch(String.fromCharCode(...[47, 98]))`, "/b"},
+		{`fetch([47, 99].map(c => String.fromCharCode(c)).join(""))`, "/c"},
+		{`fetch([50, 102].map(function(c) { return String.fromCharCode(c - 3) }).join(""))`, "/c"},
+		{`fetch("nimda/".split("").reverse().join(""))`, "/admin"},
+		{`fetch("/" + (![]+[])[+[]] + (!![]+[])[+!+[]])`, "/fr"},
+		{`fetch("/" + ([][[]]+[])[+!+[]+!+[]])`, "/d"},
+		{`fetch("/q?" + String.fromCharCode(34))`, `/q?"`},
+	}
+
+	for _, c := range cases {
+		a := NewAnalyzer([]byte(c.JS))
+		a.Deobfuscate = true
+
+		found := false
+		for _, u := range a.GetURLs() {
+			if u.URL == c.Expected {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("want URL %s from deobfuscated %s; have tree:\n%s", c.Expected, c.JS, a.RootNode().Content())
+		}
+	}
+}
+
+func TestDeobfuscateUnchanged(t *testing.T) {
+	cases := []string{
+		`fetch("/api/" + id)`,
+		`if (String.fromCharCode(65)) { go() }`,
+		`x = [1, 2].map(f).join("")`,
+		`x = String.fromCharCode(c)`,
+		`x = "a" + "b"`,
+	}
+
+	for _, c := range cases {
+		a := NewAnalyzer([]byte(c))
+		a.Deobfuscate = true
+
+		root := a.RootNode()
+		if a.HasErrors() {
+			t.Errorf("want no parse errors after deobfuscating %s; have %s", c, root.Content())
+		}
+
+		if c == `if (String.fromCharCode(65)) { go() }` {
+			if root.Content() != `if ("A") { go() }` {
+				t.Errorf("want condition to keep its parentheses; have %s", root.Content())
+			}
+			continue
+		}
+
+		if root.Content() != c {
+			t.Errorf("want %s to be unchanged by deobfuscation; have %s", c, root.Content())
+		}
+	}
+}
+
+func TestDeobfuscateLimits(t *testing.T) {
+	long := strings.Repeat("a", maxDeobfuscatedLength)
+	js := `x = String.fromCharCode(65) + "` + long + `"`
+
+	a := NewAnalyzer([]byte(js))
+	a.Deobfuscate = true
+
+	expected := `x = "A" + "` + long + `"`
+	if a.RootNode().Content() != expected {
+		t.Errorf("want strings longer than the limit not to be joined")
+	}
+
+	b := NewAnalyzer([]byte(`x = String.fromCharCode(65)`))
+	e := newEvaluator()
+	e.steps = maxDeobfuscateSteps
+	if _, ok := e.eval(b.RootNode().NamedChild(0).NamedChild(0), nil); ok {
+		t.Errorf("want evaluation to fail once the step limit is reached")
+	}
+}
+
+func TestDeobfuscateLimitsBeforeBuilding(t *testing.T) {
+	long := strings.Repeat("a", 1<<14)
+	cases := []string{
+		`var x = "` + long + `".split("").join("` + long + `")`,
+		`var x = "` + long + `".concat("` + long + `", "` + long + `", "` + long + `", "` + long + `")`,
+		`var x = "` + long + long + long + long + long + `".split("")`,
+	}
+
+	for _, js := range cases {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		a := NewAnalyzer([]byte(js))
+		a.Deobfuscate = true
+		content := a.RootNode().Content()
+
+		runtime.ReadMemStats(&after)
+
+		if content != js {
+			t.Errorf("want results longer than the limit not to be evaluated in %.40s...", js)
+		}
+
+		// the results would be gigabytes if they were built
+		if used := after.TotalAlloc - before.TotalAlloc; used > 32<<20 {
+			t.Errorf("want less than 32MB allocated for %.40s...; have %dMB", js, used>>20)
+		}
+	}
+}
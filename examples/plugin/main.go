@@ -0,0 +1,54 @@
+// This is an example of a matcher plugin for the jsluice command-line tool.
+// Build it with:
+//
+//	go build -buildmode=plugin -o matchers.so ./examples/plugin
+//
+// And then use it with:
+//
+//	jsluice urls --matcher-plugin matchers.so file.js
+//	jsluice secrets --matcher-plugin matchers.so file.js
+package main
+
+import (
+	"strings"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// URLMatchers is looked up by jsluice when the plugin is loaded
+func URLMatchers() []jsluice.URLMatcher {
+	return []jsluice.URLMatcher{
+		{Name: "mailto", Type: "string", Fn: func(n *jsluice.Node) *jsluice.URL {
+			val := n.DecodedString()
+			if !strings.HasPrefix(val, "mailto:") {
+				return nil
+			}
+
+			return &jsluice.URL{
+				URL:  val,
+				Type: "mailto",
+			}
+		}},
+	}
+}
+
+// SecretMatchers is looked up by jsluice when the plugin is loaded
+func SecretMatchers() []jsluice.SecretMatcher {
+	return []jsluice.SecretMatcher{
+		{Name: "internalToken", Query: "(string) @matches", Fn: func(n *jsluice.Node) *jsluice.Secret {
+			val := n.RawString()
+			if !strings.HasPrefix(val, "itk_") {
+				return nil
+			}
+
+			return &jsluice.Secret{
+				Kind:     "internalToken",
+				Severity: jsluice.SeverityHigh,
+				Data:     map[string]string{"token": val},
+			}
+		}},
+	}
+}
+
+// main is never called; plugins still need to be package main
+func main() {}
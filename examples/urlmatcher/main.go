@@ -19,9 +19,9 @@ func main() {
 	`))
 
 	analyzer.AddURLMatcher(
-		// The first value in the jsluice.URLMatcher struct is the type of node to look for.
+		// The Type in the jsluice.URLMatcher struct is the type of node to look for.
 		// It can be one of "string", "assignment_expression", or "call_expression"
-		jsluice.URLMatcher{"string", func(n *jsluice.Node) *jsluice.URL {
+		jsluice.URLMatcher{Name: "mailto", Type: "string", Fn: func(n *jsluice.Node) *jsluice.URL {
 			val := n.DecodedString()
 			if !strings.HasPrefix(val, "mailto:") {
 				return nil
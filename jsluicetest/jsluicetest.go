@@ -0,0 +1,73 @@
+// Package jsluicetest provides helpers for testing custom URL and secret
+// matchers. It's kept separate from the jsluice package so that programs
+// that use jsluice don't need to import the testing package.
+package jsluicetest
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/BishopFox/jsluice"
+)
+
+// AssertURLs analyzes the source with the default URL matchers and any
+// extra matchers provided, and fails the test for any of the expected
+// URLs that aren't found. Other URLs that are found are ignored, because
+// the default matchers (especially the one for string literals) usually
+// find more than the matcher being tested.
+func AssertURLs(t testing.TB, source []byte, expected []string, matchers ...jsluice.URLMatcher) {
+	t.Helper()
+
+	analyzer := jsluice.NewAnalyzer(source)
+	for _, m := range matchers {
+		analyzer.AddURLMatcher(m)
+	}
+
+	found := make(map[string]bool)
+	for _, u := range analyzer.GetURLs() {
+		found[u.URL] = true
+	}
+
+	for _, url := range expected {
+		if !found[url] {
+			t.Errorf("want URL %s; have none (found %v)", url, keys(found))
+		}
+	}
+}
+
+// AssertSecrets analyzes the source with the default secret matchers and
+// any extra matchers provided, and fails the test for any of the expected
+// kinds of secret that aren't found. A kind that's listed more than once
+// has to be found at least that many times. Other secrets are ignored.
+func AssertSecrets(t testing.TB, source []byte, expectedKinds []string, matchers ...jsluice.SecretMatcher) {
+	t.Helper()
+
+	analyzer := jsluice.NewAnalyzer(source)
+	analyzer.AddSecretMatchers(matchers)
+
+	found := make(map[string]int)
+	for _, s := range analyzer.GetSecrets() {
+		found[s.Kind]++
+	}
+
+	want := make(map[string]int)
+	for _, kind := range expectedKinds {
+		want[kind]++
+	}
+
+	for kind, count := range want {
+		if found[kind] < count {
+			t.Errorf("want %d %s secrets; have %d", count, kind, found[kind])
+		}
+	}
+}
+
+// keys returns the sorted keys of a map, for use in failure messages
+func keys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
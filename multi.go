@@ -0,0 +1,181 @@
+package jsluice
+
+import (
+	"sort"
+)
+
+// A MultiAnalyzer analyzes several JavaScript files as one logical
+// bundle. Each file is parsed separately, but string constants that
+// are declared at the top level of any file (e.g. a base URL exported
+// by a config module) are used when collapsing URLs in all of the
+// files, instead of being replaced with the expression placeholder.
+type MultiAnalyzer struct {
+	// Analyzers holds the Analyzer for each file, keyed by filename.
+	// Options (e.g. IncludeComments) can be set on them individually.
+	Analyzers map[string]*Analyzer
+}
+
+// NewMultiAnalyzer accepts a map of filenames to source code and returns
+// a pointer to a new MultiAnalyzer. Resolving constants is best-effort:
+// only string literals (and string literals in object literals) that are
+// assigned to a const at the top level of a file are used, and any names
+// that are declared with different values in more than one file are
+// ignored.
+func NewMultiAnalyzer(sources map[string][]byte) *MultiAnalyzer {
+	m := &MultiAnalyzer{
+		Analyzers: make(map[string]*Analyzer, len(sources)),
+	}
+
+	symbols := make(map[string]string)
+	conflicts := make(map[string]bool)
+
+	for filename, source := range sources {
+		a := NewAnalyzer(source)
+		m.Analyzers[filename] = a
+
+		for name, value := range a.constantSymbols() {
+			if existing, exists := symbols[name]; exists && existing != value {
+				conflicts[name] = true
+			}
+			symbols[name] = value
+		}
+	}
+
+	for name := range conflicts {
+		delete(symbols, name)
+	}
+
+	for _, a := range m.Analyzers {
+		a.symbols = symbols
+	}
+
+	return m
+}
+
+// filenames returns the filenames for the MultiAnalyzer in a stable order
+func (m *MultiAnalyzer) filenames() []string {
+	out := make([]string, 0, len(m.Analyzers))
+	for filename := range m.Analyzers {
+		out = append(out, filename)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// GetURLs returns the URLs found in all of the files, with
+// the Filename field of each URL set to the file it was found in
+func (m *MultiAnalyzer) GetURLs() []*URL {
+	out := make([]*URL, 0)
+
+	for _, filename := range m.filenames() {
+		for _, u := range m.Analyzers[filename].GetURLs() {
+			u.Filename = filename
+			out = append(out, u)
+		}
+	}
+
+	return out
+}
+
+// GetSecrets returns the secrets found in all of the files, with
+// the Filename field of each Secret set to the file it was found in
+func (m *MultiAnalyzer) GetSecrets() []*Secret {
+	out := make([]*Secret, 0)
+
+	for _, filename := range m.filenames() {
+		for _, s := range m.Analyzers[filename].GetSecrets() {
+			s.Filename = filename
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
+
+// constantSymbols returns the string constants that are declared at the
+// top level of the source, including exported constants. String values in
+// object literals are included using dotted names; e.g. config.baseURL
+func (a *Analyzer) constantSymbols() map[string]string {
+	out := make(map[string]string)
+
+	for _, statement := range a.RootNode().NamedChildren() {
+		if statement.Type() == "export_statement" {
+			statement = statement.ChildByFieldName("declaration")
+		}
+
+		if !statement.IsValid() || statement.Type() != "lexical_declaration" {
+			continue
+		}
+
+		// let declarations can be reassigned, so only const is used
+		if statement.Child(0).Content() != "const" {
+			continue
+		}
+
+		for _, declarator := range statement.NamedChildren() {
+			if declarator.Type() != "variable_declarator" {
+				continue
+			}
+
+			name := declarator.ChildByFieldName("name")
+			if name.Type() != "identifier" {
+				continue
+			}
+
+			addSymbols(out, name.Content(), declarator.ChildByFieldName("value"))
+		}
+	}
+
+	return out
+}
+
+// addSymbols adds the Node to the symbols map if it's a string, or adds
+// any string values it contains with prefixed names if it's an object
+func addSymbols(symbols map[string]string, name string, value *Node) {
+	switch value.Type() {
+	case "string":
+		symbols[name] = value.RawString()
+	case "object":
+		for _, pair := range value.NamedChildren() {
+			if pair.Type() != "pair" {
+				continue
+			}
+
+			key := pair.ChildByFieldName("key")
+			if key.Type() != "property_identifier" && key.Type() != "string" {
+				continue
+			}
+
+			addSymbols(symbols, name+"."+key.RawString(), pair.ChildByFieldName("value"))
+		}
+	}
+}
+
+// symbol returns the value of a constant declared in one of the files
+// being analyzed by a MultiAnalyzer, if the Node refers to one. Names
+// that are bound where the Node is (e.g. a parameter or a local variable
+// that shadows a constant in another file) don't refer to a constant.
+func (n *Node) symbol() (string, bool) {
+	if n.analyzer == nil || n.analyzer.symbols == nil {
+		return "", false
+	}
+
+	// e.g. config for config.endpoints.users
+	name := n
+	for name.Type() == "member_expression" {
+		name = name.ChildByFieldName("object")
+	}
+	if name.Type() != "identifier" {
+		return "", false
+	}
+
+	v, exists := n.analyzer.symbols[n.Content()]
+	if !exists {
+		return "", false
+	}
+
+	if n.assignedValue(name.Content(), n.node.StartByte()).IsValid() || n.isBound(name.Content()) {
+		return "", false
+	}
+	return v, true
+}
@@ -0,0 +1,85 @@
+package jsluice
+
+import (
+	"testing"
+)
+
+func TestMultiAnalyzer(t *testing.T) {
+	m := NewMultiAnalyzer(map[string][]byte{
+		"config.js": []byte(`
+			export const API_BASE = "https://api.example.com/v1"
+			export const config = {endpoints: {users: "/users"}}
+			const conflicting = "/one"
+			let notConstant = "/let"
+		`),
+		"app.js": []byte(`
+			const conflicting = "/two"
+			fetch(API_BASE + "/posts?id=" + postId)
+			fetch(API_BASE + config.endpoints.users)
+			fetch(conflicting + "/path")
+			fetch(notConstant + "/path")
+		`),
+	})
+
+	expected := []string{
+		"https://api.example.com/v1/posts?id=EXPR",
+		"https://api.example.com/v1/users",
+	}
+
+	seen := make(map[string]bool)
+	for _, u := range m.GetURLs() {
+		if u.Type != "fetch" {
+			continue
+		}
+
+		if u.Filename != "app.js" {
+			t.Errorf("want filename app.js for %s; have %s", u.URL, u.Filename)
+		}
+		seen[u.URL] = true
+	}
+
+	for _, url := range expected {
+		if !seen[url] {
+			t.Errorf("want fetch match for %s; have none", url)
+		}
+		delete(seen, url)
+	}
+
+	for url := range seen {
+		t.Errorf("want no fetch match for %s", url)
+	}
+}
+
+func TestMultiAnalyzerShadowing(t *testing.T) {
+	m := NewMultiAnalyzer(map[string][]byte{
+		"config.js": []byte(`
+			export const API = "https://api.example.com"
+			export const config = {base: "/v1"}
+		`),
+		"app.js": []byte(`
+			function f(API) { fetch(API + "/param") }
+			function g() { let API = getBase(); fetch(API + "/local") }
+			const h = (config) => fetch(config.base + "/member")
+			fetch(API + "/global")
+		`),
+	})
+
+	seen := make(map[string]bool)
+	for _, u := range m.GetURLs() {
+		seen[u.URL] = true
+	}
+
+	if !seen["https://api.example.com/global"] {
+		t.Errorf("want the constant from config.js to be used for a global reference")
+	}
+
+	for _, unwanted := range []string{
+		"https://api.example.com/param",
+		"https://api.example.com/local",
+		"/v1/member",
+	} {
+		if seen[unwanted] {
+			t.Errorf("want no match for %s; the name is bound locally", unwanted)
+		}
+	}
+}
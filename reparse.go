@@ -0,0 +1,119 @@
+package jsluice
+
+import (
+	"bytes"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// An Edit describes a change that was made to the source being analyzed,
+// so that Reparse can reuse the parts of the parse tree that didn't change.
+// Offsets are in bytes. The text from StartByte to OldEndByte in the old
+// source was replaced with the text from StartByte to NewEndByte in the
+// new source, and the positions are the same locations as lines and
+// columns. When there's more than one Edit, each is relative to the source
+// after the Edits before it were made, the same as for tree-sitter.
+type Edit struct {
+	StartByte  int
+	OldEndByte int
+	NewEndByte int
+
+	StartPosition  Position
+	OldEndPosition Position
+	NewEndPosition Position
+}
+
+// DiffEdit returns a single Edit that covers everything that's different
+// between the old and new source; i.e. everything between the longest
+// common prefix and the longest common suffix. It's used by Reparse when
+// no Edits are provided, and is cheap compared to parsing.
+func DiffEdit(oldSource, newSource []byte) Edit {
+	prefix := 0
+	for prefix < len(oldSource) && prefix < len(newSource) && oldSource[prefix] == newSource[prefix] {
+		prefix++
+	}
+
+	// the suffix can't overlap the prefix in either source
+	suffix := 0
+	for suffix < len(oldSource)-prefix && suffix < len(newSource)-prefix &&
+		oldSource[len(oldSource)-1-suffix] == newSource[len(newSource)-1-suffix] {
+		suffix++
+	}
+
+	e := Edit{
+		StartByte:  prefix,
+		OldEndByte: len(oldSource) - suffix,
+		NewEndByte: len(newSource) - suffix,
+	}
+	e.StartPosition = positionAt(newSource, e.StartByte)
+	e.OldEndPosition = positionAt(oldSource, e.OldEndByte)
+	e.NewEndPosition = positionAt(newSource, e.NewEndByte)
+
+	return e
+}
+
+// positionAt returns the Position of a byte offset in some source
+func positionAt(source []byte, offset int) Position {
+	before := source[:offset]
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+
+	return Position{
+		Line:   bytes.Count(before, []byte("\n")) + 1,
+		Column: offset - lineStart + 1,
+	}
+}
+
+// point converts a Position to a tree-sitter Point, which
+// counts lines and columns from zero
+func (p Position) point() sitter.Point {
+	return sitter.Point{
+		Row:    uint32(p.Line - 1),
+		Column: uint32(p.Column - 1),
+	}
+}
+
+func (e Edit) input() sitter.EditInput {
+	return sitter.EditInput{
+		StartIndex:  uint32(e.StartByte),
+		OldEndIndex: uint32(e.OldEndByte),
+		NewEndIndex: uint32(e.NewEndByte),
+		StartPoint:  e.StartPosition.point(),
+		OldEndPoint: e.OldEndPosition.point(),
+		NewEndPoint: e.NewEndPosition.point(),
+	}
+}
+
+// Reparse replaces the source being analyzed with a new version of it,
+// reusing the parts of the existing parse tree that weren't changed by
+// the edits. That's much quicker than creating a new Analyzer for small
+// changes to big files; e.g. in a file watcher or an editor. If no edits
+// are provided, a single edit is worked out with DiffEdit.
+//
+// Options and matchers are kept. The new source is interpreted the same
+// way as the old source was (e.g. as HTML), but the tree can only be
+// reused for JavaScript; anything else, and source that's been beautified
+// or deobfuscated, is parsed from scratch.
+func (a *Analyzer) Reparse(newSource []byte, edits ...Edit) {
+	var tree *sitter.Tree
+	if a.hint == ForceJS && a.tree != nil {
+		if len(edits) == 0 {
+			edits = []Edit{DiffEdit(a.rootNode.source, newSource)}
+		}
+
+		for _, e := range edits {
+			a.tree.Edit(e.input())
+		}
+		tree = a.parse(a.tree, newSource)
+	} else {
+		newSource, a.css = prepareSource(newSource, a.hint)
+		tree = a.parse(nil, newSource)
+	}
+
+	a.tree = tree
+	a.rootNode = a.newRoot(tree, newSource)
+
+	// the new source needs beautifying etc again if it's enabled
+	a.beautified = false
+	a.deobfuscated = false
+	a.truncated = false
+}
@@ -0,0 +1,114 @@
+package jsluice
+
+import (
+	"testing"
+)
+
+func TestDiffEdit(t *testing.T) {
+	old := []byte("fetch(\"/api/v1\")\nlocation.href = \"/home\"\n")
+	new := []byte("fetch(\"/api/v1\")\nlocation.href = \"/account/home\"\n")
+
+	expected := Edit{
+		StartByte:      35,
+		OldEndByte:     35,
+		NewEndByte:     43,
+		StartPosition:  Position{Line: 2, Column: 19},
+		OldEndPosition: Position{Line: 2, Column: 19},
+		NewEndPosition: Position{Line: 2, Column: 27},
+	}
+
+	actual := DiffEdit(old, new)
+	if actual != expected {
+		t.Errorf("want %+v; have %+v", expected, actual)
+	}
+
+	// an edit that repeats the text around it mustn't overlap
+	actual = DiffEdit([]byte("aaa"), []byte("aaaa"))
+	if actual.StartByte != 3 || actual.OldEndByte != 3 || actual.NewEndByte != 4 {
+		t.Errorf("want an insertion at 3; have %+v", actual)
+	}
+}
+
+func TestAnalyzerReparse(t *testing.T) {
+	old := []byte(`
+		fetch("/api/v1/users")
+		location.href = "/home"
+	`)
+	new := []byte(`
+		fetch("/api/v2/users", {method: "POST"})
+		location.href = "/home"
+		window.open("/help")
+	`)
+
+	a := NewAnalyzer(old)
+	a.GetURLs()
+	a.Reparse(new)
+
+	fresh := NewAnalyzer(new)
+
+	found := make(map[string]bool)
+	for _, u := range a.GetURLs() {
+		found[u.Type+" "+u.Method+" "+u.URL] = true
+	}
+
+	for _, u := range fresh.GetURLs() {
+		key := u.Type + " " + u.Method + " " + u.URL
+		if !found[key] {
+			t.Errorf("want %s after reparsing; have none (%v)", key, found)
+		}
+		delete(found, key)
+	}
+
+	for key := range found {
+		t.Errorf("want no %s after reparsing; have one", key)
+	}
+
+	if a.RootNode().Content() != fresh.RootNode().Content() {
+		t.Errorf("want the root node to be for the new source; have %q", a.RootNode().Content())
+	}
+}
+
+func TestAnalyzerReparseEdits(t *testing.T) {
+	old := []byte(`const a = "/one"; const b = "/two"`)
+	new := []byte(`const a = "/uno"; const b = "/dos"`)
+
+	a := NewAnalyzer(old)
+	a.Reparse(new,
+		Edit{
+			StartByte: 12, OldEndByte: 15, NewEndByte: 15,
+			StartPosition:  Position{Line: 1, Column: 13},
+			OldEndPosition: Position{Line: 1, Column: 16},
+			NewEndPosition: Position{Line: 1, Column: 16},
+		},
+		Edit{
+			StartByte: 30, OldEndByte: 33, NewEndByte: 33,
+			StartPosition:  Position{Line: 1, Column: 31},
+			OldEndPosition: Position{Line: 1, Column: 34},
+			NewEndPosition: Position{Line: 1, Column: 34},
+		},
+	)
+
+	found := make(map[string]bool)
+	for _, u := range a.GetURLs() {
+		found[u.URL] = true
+	}
+
+	if len(found) != 2 || !found["/uno"] || !found["/dos"] {
+		t.Errorf("want /uno and /dos after reparsing; have %v", found)
+	}
+}
+
+func TestAnalyzerReparseHTML(t *testing.T) {
+	a := NewAnalyzer([]byte(`<html><script>fetch("/old")</script></html>`))
+	a.IncludeStyles = true
+	a.Reparse([]byte(`<html><script>fetch("/new")</script><style>a { background: url(/bg.png) }</style></html>`))
+
+	found := make(map[string]bool)
+	for _, u := range a.GetURLs() {
+		found[u.URL] = true
+	}
+
+	if len(found) != 2 || !found["/new"] || !found["/bg.png"] {
+		t.Errorf("want /new and /bg.png after reparsing HTML; have %v", found)
+	}
+}
@@ -0,0 +1,19 @@
+package jsluice
+
+// A Report holds everything an Analyzer finds in its source
+type Report struct {
+	URLs    []*URL        `json:"urls"`
+	Secrets []*Secret     `json:"secrets"`
+	Stats   AnalyzerStats `json:"stats"`
+}
+
+// Report returns the URLs, secrets, and stats for the source being
+// analyzed. It's the same as calling GetURLs, GetSecrets, and Stats,
+// which all use the same parse tree, so the source is only parsed once.
+func (a *Analyzer) Report() Report {
+	return Report{
+		URLs:    a.GetURLs(),
+		Secrets: a.GetSecrets(),
+		Stats:   a.Stats(),
+	}
+}
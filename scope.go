@@ -0,0 +1,187 @@
+package jsluice
+
+// functionScope returns the nearest ancestor of the Node that defines
+// a function scope, or the root node if there isn't one.
+//
+// JavaScript has three types of scope: global, function, and block.
+// Block scope only comes into play if values are defined using 'let',
+// or 'const', so it's ignored here. That leaves us with global scope
+// and function scope. To find those we can ascend the tree until we hit
+// a function node of some kind, or we hit a nil parent.
+func (n *Node) functionScope() *Node {
+	scope := n
+	for {
+		candidate := scope.Parent()
+		if !candidate.IsValid() {
+			return scope
+		}
+		scope = candidate
+
+		switch scope.Type() {
+		case "function_declaration", "function", "arrow_function", "method_definition":
+			return scope
+		}
+	}
+}
+
+// isBound returns true if there is a declaration for the provided name
+// in any of the scopes that the Node is in; i.e. if the name refers to
+// a function, variable, or parameter rather than a global.
+func (n *Node) isBound(name string) bool {
+	// Collect the scopes the Node is in, from the innermost outwards
+	scopes := make([]*Node, 0)
+	for scope := n.functionScope(); ; scope = scope.functionScope() {
+		scopes = append(scopes, scope)
+		if !scope.Parent().IsValid() {
+			break
+		}
+	}
+
+	inScope := func(s *Node) bool {
+		for _, scope := range scopes {
+			if scope.node.Equal(s.node) {
+				return true
+			}
+		}
+		return false
+	}
+
+	root := scopes[len(scopes)-1]
+
+	bound := false
+	root.Query(`
+		[
+			(function_declaration name: (identifier) @name)
+			(variable_declarator name: (identifier) @name)
+			(formal_parameters (identifier) @name)
+			(arrow_function parameter: (identifier) @name)
+		]
+	`, func(decl *Node) {
+		if bound || decl.Content() != name {
+			return
+		}
+
+		// Functions are declared in the scope that contains
+		// them, not in the scope that they create
+		scope := decl.functionScope()
+		if decl.Parent().Type() == "function_declaration" {
+			scope = decl.Parent().functionScope()
+		}
+
+		bound = inScope(scope)
+	})
+
+	return bound
+}
+
+// declaredValue returns the value that the provided name was given when
+// it was declared (e.g. with var, let, or const) in one of the scopes
+// that the Node is in, before the Node. Declarations in inner scopes
+// take precedence. It returns nil if there's no such declaration, or
+// if the declaration didn't give the name a value.
+func (n *Node) declaredValue(name string) *Node {
+	return n.latestValue(name, n.node.StartByte(), `
+		(variable_declarator
+			name: (identifier) @name
+			value: (_) @value
+		)
+	`)
+}
+
+// assignedValue is like declaredValue, but plain assignments
+// (e.g. name = "value") are considered as well as declarations
+func (n *Node) assignedValue(name string, before uint32) *Node {
+	return n.latestValue(name, before, `
+		[
+			(variable_declarator
+				name: (identifier) @name
+				value: (_) @value
+			)
+			(assignment_expression
+				left: (identifier) @name
+				right: (_) @value
+			)
+		]
+	`)
+}
+
+// latestValue returns the last value given to the provided name by the
+// query, which must capture @name and @value, in one of the scopes that
+// the Node is in, before the provided byte offset. Values given in inner
+// scopes take precedence.
+func (n *Node) latestValue(name string, before uint32, query string) *Node {
+	scopes := make([]*Node, 0)
+	for scope := n.functionScope(); ; scope = scope.functionScope() {
+		scopes = append(scopes, scope)
+		if !scope.Parent().IsValid() {
+			break
+		}
+	}
+
+	depth := func(s *Node) int {
+		for i, scope := range scopes {
+			if scope.node.Equal(s.node) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var value *Node
+	best := len(scopes)
+
+	root := scopes[len(scopes)-1]
+	root.QueryMulti(query, func(qr QueryResult) {
+		decl := qr.Get("name")
+		if decl.Content() != name || decl.node.StartByte() >= before {
+			return
+		}
+
+		d := depth(decl.functionScope())
+		if d == -1 || d > best {
+			return
+		}
+
+		// a later value in the same scope replaces an earlier one
+		best = d
+		value = qr.Get("value")
+	})
+
+	return value
+}
+
+// ResolveIdentifier returns the string that was most recently assigned
+// to the provided name (with var, let, const, or a plain assignment)
+// before the scope Node, in any of the function scopes that the scope
+// Node is in. The scope Node is usually where the value is needed; e.g.
+// the fetch call that uses the identifier. If scope is nil, the last
+// assignment at the top level of the source is used.
+//
+// Only string literals are resolved: if the most recent assignment is
+// a computed value (e.g. a concatenation or a function call), the name
+// can't be resolved. Constants from other files are used as a fallback
+// when the Analyzer is part of a MultiAnalyzer.
+func (a *Analyzer) ResolveIdentifier(name string, scope *Node) (string, bool) {
+	var end uint32
+	switch {
+	case scope == nil:
+		scope = a.RootNode()
+		end = uint32(len(scope.source))
+	case scope.IsValid():
+		end = scope.node.StartByte()
+	}
+	if !scope.IsValid() {
+		return "", false
+	}
+
+	value := scope.assignedValue(name, end)
+	if value.IsValid() {
+		if value.Type() != "string" {
+			return "", false
+		}
+		return value.RawString(), true
+	}
+
+	v, exists := a.symbols[name]
+	return v, exists
+}
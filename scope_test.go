@@ -0,0 +1,86 @@
+package jsluice
+
+import (
+	"testing"
+)
+
+func TestBareOpen(t *testing.T) {
+	cases := []struct {
+		JS       string
+		expected bool
+	}{
+		{`open("/popup")`, true},
+		{`window.open("/popup")`, true},
+		{`function go(){ open("/popup") }`, true},
+		{`function open(p){} open("/tmp/file")`, false},
+		{`const open = require("open"); open("/tmp/file")`, false},
+		{`function read(open){ open("/tmp/file") }`, false},
+		{`const read = open => open("/tmp/file")`, false},
+		{`function outer(){ var open = fs.open; function inner(){ open("/tmp/file") } }`, false},
+
+		// declarations in other scopes don't count
+		{`function other(){ var open = fs.open } open("/popup")`, true},
+		{`function other(open){} open("/popup")`, true},
+	}
+
+	for _, c := range cases {
+		a := NewAnalyzer([]byte(c.JS))
+
+		found := false
+		for _, u := range a.GetURLs() {
+			if u.Type == "window.open" {
+				found = true
+			}
+		}
+
+		if found != c.expected {
+			t.Errorf("want %t for window.open match in %s; have %t", c.expected, c.JS, found)
+		}
+	}
+}
+
+func TestResolveIdentifier(t *testing.T) {
+	a := NewAnalyzer([]byte(`
+		var base = "/api/v1"
+		const key = getKey()
+		var host = "example.com"
+		function load() {
+			var base = "/api/v2"
+			fetch(base + "/users")
+			base = "/api/v3"
+		}
+		fetch(base + "/items")
+		base = "/api/v4"
+	`))
+
+	calls := make([]*Node, 0)
+	a.Query("(call_expression function: (identifier) @fn (#eq? @fn \"fetch\")) @call", func(n *Node) {
+		if n.CaptureName() == "call" {
+			calls = append(calls, n)
+		}
+	})
+	if len(calls) != 2 {
+		t.Fatalf("want 2 fetch calls; have %d", len(calls))
+	}
+
+	cases := []struct {
+		name     string
+		scope    *Node
+		expected string
+		ok       bool
+	}{
+		{"base", calls[0], "/api/v2", true},
+		{"base", calls[1], "/api/v1", true},
+		{"base", nil, "/api/v4", true},
+		{"host", calls[0], "example.com", true},
+		{"key", calls[1], "", false},
+		{"missing", nil, "", false},
+	}
+
+	for _, c := range cases {
+		actual, ok := a.ResolveIdentifier(c.name, c.scope)
+		if actual != c.expected || ok != c.ok {
+			t.Errorf("want %q, %t for ResolveIdentifier(%s, %s); have %q, %t", c.expected, c.ok, c.name, c.scope, actual, ok)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package jsluice
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// authorizationSchemes are the (lowercased) schemes in Authorization
+// headers that are followed by a credential
+var authorizationSchemes = map[string]string{
+	"bearer": "Bearer",
+	"basic":  "Basic",
+	"token":  "token",
+}
+
+// authorizationHeaderMatcher finds hardcoded credentials in Authorization
+// headers. Headers are usually set in an object (e.g. for fetch, axios, or
+// jQuery), so all pairs are checked regardless of which library they're
+// for, as are calls like xhr.setRequestHeader("Authorization", "...").
+func authorizationHeaderMatcher() SecretMatcher {
+
+	return SecretMatcher{Name: "authorizationHeader", Kind: "authorizationHeader", Severity: SeverityMedium, Query: "[(pair) (call_expression)] @matches", Fn: func(n *Node) *Secret {
+		var name, value *Node
+
+		switch n.Type() {
+		case "pair":
+			name = n.ChildByFieldName("key")
+			value = n.ChildByFieldName("value")
+
+		case "call_expression":
+			fn := n.ChildByFieldName("function")
+			if fn.Type() != "member_expression" {
+				return nil
+			}
+			switch fn.ChildByFieldName("property").Content() {
+			case "setRequestHeader", "set", "append":
+			default:
+				return nil
+			}
+
+			args := n.ChildByFieldName("arguments")
+			name = args.NamedChild(0)
+			value = args.NamedChild(1)
+		}
+
+		if !name.IsValid() || !value.IsValid() || !value.IsStringy() {
+			return nil
+		}
+		if !strings.EqualFold(name.RawString(), "authorization") {
+			return nil
+		}
+
+		// The credential often comes from a variable, in which
+		// case it's collapsed to the placeholder and isn't a secret
+		header := value.CollapsedString()
+		scheme, credential, found := strings.Cut(header, " ")
+		if !found {
+			return nil
+		}
+
+		scheme, known := authorizationSchemes[strings.ToLower(scheme)]
+		credential = strings.TrimSpace(credential)
+		if !known || !isCredential(credential, n.placeholder()) {
+			return nil
+		}
+
+		data := map[string]string{
+			"scheme": scheme,
+			"token":  credential,
+		}
+
+		// Basic credentials are just base64 encoded
+		if scheme == "Basic" {
+			if decoded, err := base64.StdEncoding.DecodeString(credential); err == nil {
+				if user, pass, found := strings.Cut(string(decoded), ":"); found {
+					data["username"] = user
+					data["password"] = pass
+				}
+			}
+		}
+
+		match := &Secret{
+			Kind:     "authorizationHeader",
+			Severity: SeverityMedium,
+			Data:     data,
+		}
+
+		// Include the rest of the headers as context
+		if parent := n.Parent(); n.Type() == "pair" && parent.Type() == "object" {
+			match.Context = parent.AsObject().AsMap()
+		}
+
+		return match
+	}}
+}
+
+// isCredential returns true if a value from an Authorization header
+// looks like a real credential rather than a placeholder or an expression;
+// e.g. "Bearer <token>", "Bearer YOUR_TOKEN" or "Bearer " + token
+func isCredential(credential, placeholder string) bool {
+	if len(credential) < 8 || strings.Contains(credential, placeholder) {
+		return false
+	}
+
+	if strings.ContainsAny(credential, " <>{}$") {
+		return false
+	}
+
+	// e.g. YOUR_API_TOKEN or TOKEN
+	if strings.ToUpper(credential) == credential && !strings.ContainsAny(credential, "0123456789") {
+		return false
+	}
+
+	return true
+}
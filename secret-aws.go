@@ -8,7 +8,7 @@ import (
 func awsMatcher() SecretMatcher {
 	awsKey := regexp.MustCompile("^\\w+$")
 
-	return SecretMatcher{"(string) @matches", func(n *Node) *Secret {
+	return SecretMatcher{Name: "AWSAccessKey", Kind: "AWSAccessKey", Severity: SeverityLow, Query: "(string) @matches", Fn: func(n *Node) *Secret {
 		str := n.RawString()
 
 		// https://docs.aws.amazon.com/STS/latest/APIReference/API_Credentials.html
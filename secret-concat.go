@@ -0,0 +1,128 @@
+package jsluice
+
+import (
+	"bytes"
+	"strings"
+)
+
+// joinedString is a string Node for a concatenation of string
+// literals, along with the literals that it was made from
+type joinedString struct {
+	*Node
+	parts []*Node
+}
+
+// hasPartIn returns true if any of the literals that a joined
+// string was made from start at one of the given bytes
+func (j joinedString) hasPartIn(starts map[uint32]bool) bool {
+	for _, p := range j.parts {
+		if starts[p.node.StartByte()] {
+			return true
+		}
+	}
+	return false
+}
+
+// joinedStrings returns string Nodes for any concatenations in the source
+// that are made up entirely of string literals; e.g. "AKIA" + "IOSF" + "...".
+// Secrets are sometimes split up like that to stop them being found, but the
+// string secret matchers only ever see the individual parts.
+//
+// Each concatenation is replaced with a single string in a copy of the source,
+// which is then parsed again, so that the returned Nodes have the same parents
+// (e.g. a pair in an object) as the concatenations they replaced. Nothing is
+// parsed if there are no concatenations to replace.
+func (a *Analyzer) joinedStrings() []joinedString {
+	out := make([]joinedString, 0)
+
+	root := a.RootNode()
+	source := root.source
+
+	buf := &bytes.Buffer{}
+	starts := make(map[uint32][]*Node)
+	last := uint32(0)
+
+	a.Query("(binary_expression) @matches", func(n *Node) {
+		// Only the outermost expression in a concatenation is of interest
+		parent := n.Parent()
+		for parent.Type() == "parenthesized_expression" {
+			parent = parent.Parent()
+		}
+		if parent.Type() == "binary_expression" {
+			return
+		}
+
+		parts, ok := literalParts(n)
+		if !ok || len(parts) < 2 {
+			return
+		}
+
+		joined := &strings.Builder{}
+		for _, p := range parts {
+			joined.WriteString(p.RawString())
+		}
+
+		// the joined string has to be valid inside double quotes
+		if strings.ContainsAny(joined.String(), "\"\n") {
+			return
+		}
+
+		buf.Write(source[last:n.node.StartByte()])
+		starts[uint32(buf.Len())] = parts
+		buf.WriteString(`"` + joined.String() + `"`)
+
+		// a concatenation can span several lines, and keeping the lines
+		// after it in the same place means the positions of any secrets
+		// found in the joined source are the same as in the original
+		buf.Write(bytes.Repeat([]byte("\n"), bytes.Count(source[n.node.StartByte():n.node.EndByte()], []byte("\n"))))
+		last = n.node.EndByte()
+	})
+
+	if len(starts) == 0 {
+		return out
+	}
+	buf.Write(source[last:])
+
+	joinedSource := buf.Bytes()
+	joinedRoot := a.newRoot(a.parse(nil, joinedSource), joinedSource)
+
+	joinedRoot.Query("(string) @matches", func(n *Node) {
+		if parts, exists := starts[n.node.StartByte()]; exists {
+			out = append(out, joinedString{n, parts})
+		}
+	})
+
+	return out
+}
+
+// literalParts returns the string literals that make up a concatenation,
+// and false if any part of the concatenation isn't a string literal
+func literalParts(n *Node) ([]*Node, bool) {
+	switch n.Type() {
+	case "string":
+		return []*Node{n}, true
+
+	case "parenthesized_expression":
+		return literalParts(n.NamedChild(0))
+
+	case "binary_expression":
+		if n.ChildByFieldName("operator").Content() != "+" {
+			return nil, false
+		}
+
+		left, ok := literalParts(n.ChildByFieldName("left"))
+		if !ok {
+			return nil, false
+		}
+
+		right, ok := literalParts(n.ChildByFieldName("right"))
+		if !ok {
+			return nil, false
+		}
+
+		return append(left, right...), true
+
+	default:
+		return nil, false
+	}
+}
@@ -8,7 +8,7 @@ import (
 func gcpKeyMatcher() SecretMatcher {
 	gcpKey := regexp.MustCompile("^AIza[a-zA-Z0-9+_-]+$")
 
-	return SecretMatcher{"(string) @matches", func(n *Node) *Secret {
+	return SecretMatcher{Name: "gcpKey", Kind: "gcpKey", Severity: SeverityLow, Query: "(string) @matches", Fn: func(n *Node) *Secret {
 		str := n.RawString()
 
 		// Prefix check is nice and fast so we'll do that first
@@ -50,27 +50,9 @@ func gcpKeyMatcher() SecretMatcher {
 
 func firebaseMatcher() SecretMatcher {
 	// Firebase objects
-	return SecretMatcher{"(object) @matches", func(n *Node) *Secret {
+	return SecretMatcher{Name: "firebase", Kind: "firebase", Severity: SeverityHigh, Query: "(object) @matches", Fn: func(n *Node) *Secret {
 		o := n.AsObject()
-
-		mustHave := map[string]bool{
-			"apiKey":        true,
-			"authDomain":    true,
-			"projectId":     true,
-			"storageBucket": true,
-		}
-
-		count := 0
-		for _, k := range o.GetKeys() {
-			if mustHave[k] {
-				count++
-			}
-		}
-		if count != len(mustHave) {
-			return nil
-		}
-
-		if !strings.HasPrefix(o.GetStringI("apiKey", ""), "AIza") {
+		if !isFirebaseConfig(o) {
 			return nil
 		}
 
@@ -81,3 +63,27 @@ func firebaseMatcher() SecretMatcher {
 		}
 	}}
 }
+
+// isFirebaseConfig returns true if an object looks like the config that's
+// passed to firebase.initializeApp; i.e. it has an apiKey, authDomain,
+// projectId, and storageBucket, and the apiKey is a Google API key
+func isFirebaseConfig(o Object) bool {
+	mustHave := map[string]bool{
+		"apiKey":        true,
+		"authDomain":    true,
+		"projectId":     true,
+		"storageBucket": true,
+	}
+
+	count := 0
+	for _, k := range o.GetKeys() {
+		if mustHave[k] {
+			count++
+		}
+	}
+	if count != len(mustHave) {
+		return false
+	}
+
+	return strings.HasPrefix(o.GetStringI("apiKey", ""), "AIza")
+}
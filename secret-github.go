@@ -7,7 +7,7 @@ import (
 func githubKeyMatcher() SecretMatcher {
 	githubKey := regexp.MustCompile("([a-zA-Z0-9_-]{2,}:)?ghp_[a-zA-Z0-9]{30,}")
 
-	return SecretMatcher{"(string) @matches", func(n *Node) *Secret {
+	return SecretMatcher{Name: "githubKey", Kind: "githubKey", Severity: SeverityLow, Query: "(string) @matches", Fn: func(n *Node) *Secret {
 		str := n.RawString()
 
 		if !githubKey.MatchString(str) {
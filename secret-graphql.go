@@ -0,0 +1,245 @@
+package jsluice
+
+import (
+	"regexp"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+var (
+	graphqlHash          = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+	graphqlOperationName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	graphqlDocument      = regexp.MustCompile(`^\s*(?:(query|mutation|subscription|fragment)\b\s*([A-Za-z_][A-Za-z0-9_]*)?|\{)`)
+	graphqlEndpoint      = regexp.MustCompile(`(?i)^(https?:)?//[^\s]*graphql|^/[^\s]*graphql`)
+)
+
+// graphqlValueKeys are the keys used in objects in operation maps
+var graphqlValueKeys = map[string]bool{
+	"query":      true,
+	"body":       true,
+	"hash":       true,
+	"sha256Hash": true,
+	"id":         true,
+}
+
+// GraphQLOperation is a single operation found in a GraphQL
+// persisted query map by the graphqlOperations matcher
+type GraphQLOperation struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Hash  string `json:"hash,omitempty"`
+	Query string `json:"query,omitempty"`
+}
+
+// graphqlMatcher finds the maps of operation names to SHA-256 hashes
+// and/or query text that are shipped for Apollo persisted queries, e.g:
+//
+//	{GetUser: "ecf4edb4...", ListPosts: "9d0a1b2c..."}
+//	{GetUser: {hash: "ecf4edb4...", query: "query GetUser { ... }"}}
+//	{"ecf4edb4...": "query GetUser { ... }"}
+//	{operations: [{id: "ecf4edb4...", name: "GetUser", type: "query", body: "..."}]}
+//
+// That gives a full inventory of the GraphQL operations an app uses.
+// Any GraphQL endpoint found in the same source is included too.
+func graphqlMatcher() SecretMatcher {
+	var endpointRoot *sitter.Node
+	var endpoint string
+
+	findEndpoint := func(n *Node) string {
+		root := n
+		for root.Parent().IsValid() {
+			root = root.Parent()
+		}
+
+		if root.node == endpointRoot {
+			return endpoint
+		}
+		endpointRoot = root.node
+		endpoint = ""
+
+		root.Query("(string) @matches", func(s *Node) {
+			if endpoint != "" {
+				return
+			}
+			if str := s.RawString(); graphqlEndpoint.MatchString(str) {
+				endpoint = str
+			}
+		})
+		return endpoint
+	}
+
+	return SecretMatcher{Name: "graphqlOperations", Kind: "graphqlOperations", Severity: SeverityInfo, Query: "(object) @matches", Fn: func(n *Node) *Secret {
+		ops := manifestOperations(n)
+		if ops == nil {
+			ops = mapOperations(n)
+		}
+		if len(ops) == 0 {
+			return nil
+		}
+
+		data := map[string]any{
+			"operations": ops,
+		}
+		if e := findEndpoint(n); e != "" {
+			data["endpoint"] = e
+		}
+
+		return &Secret{
+			Kind:     "graphqlOperations",
+			Severity: SeverityInfo,
+			Data:     data,
+		}
+	}}
+}
+
+// manifestOperations returns the operations from an Apollo persisted
+// query manifest, which has an operations key containing an array of
+// objects with id, name, type and body keys
+func manifestOperations(n *Node) []GraphQLOperation {
+	list := n.AsObject().GetNode("operations")
+	if !list.IsValid() || list.Type() != "array" {
+		return nil
+	}
+
+	ops := make([]GraphQLOperation, 0)
+	for _, item := range list.NamedChildren() {
+		if item.Type() != "object" {
+			return nil
+		}
+		o := item.AsObject()
+
+		op := GraphQLOperation{
+			Name:  o.GetString("name", ""),
+			Type:  o.GetString("type", ""),
+			Hash:  o.GetString("id", ""),
+			Query: graphqlQueryText(o.GetNode("body")),
+		}
+		if !graphqlHash.MatchString(op.Hash) && !graphqlDocument.MatchString(op.Query) {
+			return nil
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// mapOperations returns the operations from an object that maps operation
+// names or hashes to hashes, query text, or objects containing them. Every
+// pair in the object has to look like an operation.
+func mapOperations(n *Node) []GraphQLOperation {
+	ops := make([]GraphQLOperation, 0)
+	hashesOnly := true
+
+	for _, pair := range n.NamedChildren() {
+		if pair.Type() == "comment" {
+			continue
+		}
+		if pair.Type() != "pair" {
+			return nil
+		}
+
+		key := pair.ChildByFieldName("key")
+		if key.Type() != "property_identifier" && key.Type() != "string" {
+			return nil
+		}
+		name := key.RawString()
+
+		// These are the keys in the objects that operations map to, which
+		// are also found by the matcher's query, but aren't operations
+		if graphqlValueKeys[name] {
+			return nil
+		}
+
+		op, ok := graphqlOperationValue(pair.ChildByFieldName("value"))
+		if !ok {
+			return nil
+		}
+
+		switch {
+		case graphqlHash.MatchString(name) && op.Hash == "" && op.Query != "":
+			// maps of hashes to query text, where the name (if
+			// there is one) comes from the query
+			op.Hash = name
+		case graphqlOperationName.MatchString(name):
+			op.Name = name
+		default:
+			return nil
+		}
+
+		if op.Query != "" {
+			hashesOnly = false
+		}
+		ops = append(ops, op)
+	}
+
+	// A single hash on its own is more likely to be something like
+	// {sha256Hash: "..."} in a request than an operation map
+	if hashesOnly && len(ops) < 2 {
+		return nil
+	}
+	return ops
+}
+
+// graphqlOperationValue returns an operation for the value in an operation
+// map, which is either a hash, query text, or an object containing them
+func graphqlOperationValue(v *Node) (GraphQLOperation, bool) {
+	op := GraphQLOperation{}
+
+	switch v.Type() {
+	case "string":
+		str := v.RawString()
+		if graphqlHash.MatchString(str) {
+			op.Hash = str
+			break
+		}
+		op.Query = graphqlQueryText(v)
+		if op.Query == "" {
+			return op, false
+		}
+
+	case "object":
+		o := v.AsObject()
+		for _, k := range []string{"sha256Hash", "hash", "id"} {
+			// id isn't always a hash, so the first hash-like value is used
+			if h := o.GetString(k, ""); graphqlHash.MatchString(h) {
+				op.Hash = h
+				break
+			}
+		}
+
+		query := o.GetNode("query")
+		op.Query = graphqlQueryText(query)
+		if query.IsValid() && op.Query == "" {
+			return op, false
+		}
+		if op.Query == "" && op.Hash == "" {
+			return op, false
+		}
+
+	default:
+		return op, false
+	}
+
+	if m := graphqlDocument.FindStringSubmatch(op.Query); m != nil {
+		// a document starting with { is shorthand for an anonymous query
+		op.Type = "query"
+		if m[1] != "" {
+			op.Type = m[1]
+		}
+		op.Name = m[2]
+	}
+	return op, true
+}
+
+// graphqlQueryText returns the decoded value of a string node if it
+// looks like a GraphQL document, and an empty string otherwise
+func graphqlQueryText(n *Node) string {
+	if !n.IsValid() || n.Type() != "string" {
+		return ""
+	}
+
+	str := n.DecodedString()
+	if !graphqlDocument.MatchString(str) {
+		return ""
+	}
+	return str
+}
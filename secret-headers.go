@@ -0,0 +1,106 @@
+package jsluice
+
+import (
+	"strings"
+)
+
+// securityHeaders are the (lowercased) names of response headers that
+// are interesting when auditing how an application is configured.
+// Headers that start with access-control- are included too.
+var securityHeaders = map[string]bool{
+	"content-security-policy":             true,
+	"content-security-policy-report-only": true,
+	"strict-transport-security":           true,
+	"x-frame-options":                     true,
+	"x-content-type-options":              true,
+	"x-xss-protection":                    true,
+	"referrer-policy":                     true,
+	"permissions-policy":                  true,
+	"feature-policy":                      true,
+	"cross-origin-opener-policy":          true,
+	"cross-origin-embedder-policy":        true,
+	"cross-origin-resource-policy":        true,
+}
+
+func isSecurityHeader(name string) bool {
+	name = strings.ToLower(name)
+	return securityHeaders[name] || strings.HasPrefix(name, "access-control-")
+}
+
+func securityHeaderMatcher() SecretMatcher {
+
+	return SecretMatcher{Name: "securityHeader", Kind: "securityHeader", Severity: SeverityInfo, Query: "(call_expression) @matches", Fn: func(n *Node) *Secret {
+		// Server-side code, and mock servers that end up bundled with
+		// client-side code, set response headers with calls like:
+		//   res.setHeader("Content-Security-Policy", "default-src 'self'")
+		//   res.set("Access-Control-Allow-Origin", "*")
+		//   res.set({"X-Frame-Options": "DENY"})
+		fn := n.ChildByFieldName("function")
+		if fn.Type() != "member_expression" {
+			return nil
+		}
+
+		switch fn.ChildByFieldName("property").Content() {
+		case "setHeader", "set":
+		default:
+			return nil
+		}
+
+		args := n.ChildByFieldName("arguments")
+		first := args.NamedChild(0)
+
+		headers := make(map[string]string)
+		switch first.Type() {
+		case "string":
+			headers[first.RawString()] = headerValue(args.NamedChild(1))
+
+		case "object":
+			for _, pair := range first.NamedChildren() {
+				if pair.Type() != "pair" {
+					continue
+				}
+
+				key := pair.ChildByFieldName("key")
+				if key.Type() != "property_identifier" && key.Type() != "string" {
+					continue
+				}
+
+				headers[key.RawString()] = headerValue(pair.ChildByFieldName("value"))
+			}
+
+		default:
+			return nil
+		}
+
+		// Lots of things have a set method (e.g. Map), so only
+		// the headers we know are interesting are kept
+		for name := range headers {
+			if !isSecurityHeader(name) {
+				delete(headers, name)
+			}
+		}
+
+		if len(headers) == 0 {
+			return nil
+		}
+
+		return &Secret{
+			Kind:     "securityHeader",
+			Severity: SeverityInfo,
+			Data:     headers,
+		}
+	}}
+}
+
+// headerValue returns the value of a header as a string, with any
+// expressions replaced with the expression placeholder. An empty
+// string is returned if there is no value.
+func headerValue(n *Node) string {
+	if !n.IsValid() {
+		return ""
+	}
+	if !n.IsStringy() {
+		return n.placeholder()
+	}
+	return n.CollapsedString()
+}
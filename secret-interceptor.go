@@ -0,0 +1,107 @@
+package jsluice
+
+import (
+	"regexp"
+)
+
+// interceptorName matches the names of functions that are usually request
+// interceptors; e.g. setRequestInterceptors, or axios.interceptors.request.use
+var interceptorName = regexp.MustCompile(`(?i)intercept`)
+
+// headersObject matches expressions for a request's headers, including
+// axios's defaults for all requests or requests with a given method;
+// e.g. config.headers, or config.headers.common
+var headersObject = regexp.MustCompile(`(^|\.)headers(\.(common|get|post|put|patch|delete))?$`)
+
+// interceptorHeadersMatcher finds the headers that are added to requests
+// by interceptors, like those set up with axios.interceptors.request.use.
+// They aren't secrets themselves, but custom headers like access_token or
+// application_key say a lot about how an API expects to be called, and
+// they're otherwise easy to miss because the URLs are elsewhere.
+func interceptorHeadersMatcher() SecretMatcher {
+	query := "[(function) (function_declaration) (arrow_function) (method_definition)] @matches"
+
+	return SecretMatcher{Name: "interceptorHeaders", Kind: "interceptorHeaders", Severity: SeverityInfo, Query: query, Fn: func(n *Node) *Secret {
+		name := functionName(n)
+		if !interceptorName.MatchString(name) {
+			return nil
+		}
+
+		headers := make(map[string]string)
+		n.Query("(assignment_expression) @matches", func(assignment *Node) {
+			left := assignment.ChildByFieldName("left")
+			right := assignment.ChildByFieldName("right")
+
+			// e.g. config.headers = {"X-Api-Key": key}
+			if headersObject.MatchString(left.Content()) && right.Type() == "object" {
+				for _, pair := range right.NamedChildren() {
+					if pair.Type() != "pair" {
+						continue
+					}
+					key := pair.ChildByFieldName("key").RawString()
+					headers[key] = headerValue(pair.ChildByFieldName("value"))
+				}
+				return
+			}
+
+			var header string
+			switch left.Type() {
+			case "member_expression":
+				// e.g. config.headers.Authorization = ...
+				header = left.ChildByFieldName("property").Content()
+			case "subscript_expression":
+				// e.g. e.headers["access_token"] = ...
+				index := left.ChildByFieldName("index")
+				if index.Type() != "string" {
+					return
+				}
+				header = index.RawString()
+			default:
+				return
+			}
+
+			if !headersObject.MatchString(left.ChildByFieldName("object").Content()) {
+				return
+			}
+			headers[header] = headerValue(right)
+		})
+
+		if len(headers) == 0 {
+			return nil
+		}
+
+		return &Secret{
+			Kind:     "interceptorHeaders",
+			Severity: SeverityInfo,
+			Data:     headers,
+			Context:  map[string]string{"function": name},
+		}
+	}}
+}
+
+// functionName returns the name of a function, or of whatever it's
+// assigned to or passed to; e.g. handler for const handler = () => {},
+// or axios.interceptors.request.use for a function that's passed to it
+func functionName(n *Node) string {
+	switch n.Type() {
+	case "function_declaration", "method_definition":
+		return n.ChildByFieldName("name").Content()
+	}
+
+	parent := n.Parent()
+	switch parent.Type() {
+	case "variable_declarator":
+		return parent.ChildByFieldName("name").Content()
+	case "pair":
+		return parent.ChildByFieldName("key").RawString()
+	case "assignment_expression":
+		return parent.ChildByFieldName("left").Content()
+	case "arguments":
+		return parent.Parent().ChildByFieldName("function").Content()
+	default:
+		if n.Type() == "function" {
+			return n.ChildByFieldName("name").Content()
+		}
+		return ""
+	}
+}
@@ -1,6 +1,7 @@
 package jsluice
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -12,6 +13,35 @@ type Secret struct {
 	Filename string   `json:"filename,omitempty"`
 	Severity Severity `json:"severity"`
 	Context  any      `json:"context"`
+
+	// where the secret was found in the source that was analyzed
+	Position *Position `json:"position,omitempty"`
+}
+
+// Less reports whether the Secret should sort before the other Secret.
+// Secrets are ordered by filename, then by where they were found in the
+// file, then by kind, and then by their data.
+func (s *Secret) Less(other *Secret) bool {
+	if s.Filename != other.Filename {
+		return s.Filename < other.Filename
+	}
+	if c := comparePositions(s.Position, other.Position); c != 0 {
+		return c < 0
+	}
+	if s.Kind != other.Kind {
+		return s.Kind < other.Kind
+	}
+	return fmt.Sprint(s.Data) < fmt.Sprint(other.Data)
+}
+
+// setPosition sets the position of a secret to the position of the node it
+// was found in, unless the matcher that found it has already set one
+func (s *Secret) setPosition(n *Node) {
+	if s.Position != nil {
+		return
+	}
+	position := n.Position()
+	s.Position = &position
 }
 
 // Severity indicates how serious a finding is
@@ -24,6 +54,39 @@ const (
 	SeverityHigh   Severity = "high"
 )
 
+// severityLevels defines the ordering of the severities
+var severityLevels = map[Severity]int{
+	SeverityInfo:   0,
+	SeverityLow:    1,
+	SeverityMedium: 2,
+	SeverityHigh:   3,
+}
+
+// Level returns a number that can be used to compare and sort
+// severities. Higher numbers are more severe, so that:
+//
+//	info < low < medium < high
+//
+// Invalid severities have a level of -1
+func (s Severity) Level() int {
+	level, exists := severityLevels[s]
+	if !exists {
+		return -1
+	}
+	return level
+}
+
+// Valid returns true if the Severity is one of the defined severities
+func (s Severity) Valid() bool {
+	_, exists := severityLevels[s]
+	return exists
+}
+
+// String returns the Severity as a string
+func (s Severity) String() string {
+	return string(s)
+}
+
 // AddSecretMatcher allows custom SecretMatchers to be added to the Analyzer
 func (a *Analyzer) AddSecretMatcher(s SecretMatcher) {
 	if a.userSecretMatchers == nil {
@@ -44,8 +107,11 @@ func (a *Analyzer) AddSecretMatchers(ss []SecretMatcher) {
 
 // GetSecrets uses the parse tree and a set of Matchers (those provided
 // by AllSecretMatchers()) to find secrets in JavaScript source code.
+// If MaxResults is set, no more than that many secrets are returned,
+// and Truncated reports whether any were dropped.
 func (a *Analyzer) GetSecrets() []*Secret {
 	out := make([]*Secret, 0)
+	a.truncated = false
 
 	// we only want to run each query once so let's cache them
 	nodeCache := make(map[string][]*Node)
@@ -56,7 +122,10 @@ func (a *Analyzer) GetSecrets() []*Secret {
 		matchers = append(matchers, a.userSecretMatchers...)
 	}
 
-	for _, m := range matchers {
+	// the string literals that each matcher found secrets in, by start byte
+	matchedStrings := make(map[int]map[uint32]bool)
+
+	for i, m := range matchers {
 		if _, exists := nodeCache[m.Query]; !exists {
 			nodes := make([]*Node, 0)
 			a.Query(m.Query, func(n *Node) {
@@ -72,19 +141,74 @@ func (a *Analyzer) GetSecrets() []*Secret {
 			if match == nil {
 				continue
 			}
+			match.setPosition(n)
+
+			if m.Query == "(string) @matches" {
+				if matchedStrings[i] == nil {
+					matchedStrings[i] = make(map[uint32]bool)
+				}
+				matchedStrings[i][n.node.StartByte()] = true
+			}
 
+			if a.atLimit(len(out)) {
+				return out
+			}
 			out = append(out, match)
 		}
 	}
+
+	// Matchers for strings are also run against any concatenations
+	// of string literals, which are only found if they're needed
+	var joined []joinedString
+	for i, m := range matchers {
+		if m.Query != "(string) @matches" {
+			continue
+		}
+
+		if joined == nil {
+			joined = a.joinedStrings()
+		}
+
+		for _, j := range joined {
+			// a part that's a secret by itself has already been found,
+			// and the joined string is probably just that plus a suffix
+			if j.hasPartIn(matchedStrings[i]) {
+				continue
+			}
+
+			n := j.Node
+			match := m.Fn(n)
+			if match == nil {
+				continue
+			}
+			match.setPosition(n)
+
+			if a.atLimit(len(out)) {
+				return out
+			}
+			out = append(out, match)
+		}
+	}
+
 	return out
 }
 
-// A SecretMatcher is a tree-sitter query to find relevant nodes
-// in the parse tree, and a function to inspect those nodes,
+// A SecretMatcher has a name, a tree-sitter query to find relevant
+// nodes in the parse tree, and a function to inspect those nodes,
 // returning any Secret that is found.
 type SecretMatcher struct {
 	Query string
 	Fn    func(*Node) *Secret
+
+	Name string
+
+	// Kind and Severity describe the secrets that the matcher finds
+	// (e.g. for --list-matchers), but they aren't applied to them; the
+	// Kind and Severity of each Secret are what's reported. Some matchers
+	// report a higher severity when they find more; e.g. an AWS key with
+	// its secret.
+	Kind     string
+	Severity Severity
 }
 
 // AllSecretMatchers returns the default list of SecretMatchers
@@ -95,9 +219,16 @@ func AllSecretMatchers() []SecretMatcher {
 		gcpKeyMatcher(),
 		firebaseMatcher(),
 		githubKeyMatcher(),
+		securityHeaderMatcher(),
+		graphqlMatcher(),
+		openRedirectMatcher(),
+		authorizationHeaderMatcher(),
+		storageTokenReadMatcher(),
+		s3BucketMatcher(),
+		interceptorHeadersMatcher(),
 
 		// REACT_APP_... containing objects
-		{"(object) @matches", func(n *Node) *Secret {
+		{Name: "reactApp", Kind: "reactApp", Query: "(object) @matches", Fn: func(n *Node) *Secret {
 
 			// disabled due to high false positive rate
 			return nil
@@ -123,7 +254,7 @@ func AllSecretMatchers() []SecretMatcher {
 		}},
 
 		// generic secrets
-		{"(pair) @matches", func(n *Node) *Secret {
+		{Name: "genericSecret", Kind: "genericSecret", Query: "(pair) @matches", Fn: func(n *Node) *Secret {
 
 			// disabled due to very high false positive rate
 			// but left easy to enable for research purposes
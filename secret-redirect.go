@@ -0,0 +1,202 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redirectSinks are the things that navigate to a URL when they're
+// assigned to. Each of them can also have .href on the end.
+var redirectSinks = newSet([]string{
+	"location",
+	"window.location",
+	"document.location",
+	"self.location",
+	"top.location",
+	"parent.location",
+})
+
+// redirectCalls are the functions that navigate to the URL they're passed
+var redirectCalls = newSet([]string{
+	"location.assign",
+	"location.replace",
+	"window.location.assign",
+	"window.location.replace",
+	"document.location.assign",
+	"document.location.replace",
+	"window.open",
+})
+
+// redirectSources are the properties that hold a value the user
+// controls, like the query string, the fragment, or the referrer
+var redirectSources = newSet([]string{
+	"location.search",
+	"location.hash",
+	"window.location.search",
+	"window.location.hash",
+	"document.location.search",
+	"document.location.hash",
+	"document.referrer",
+})
+
+// redirectParams matches the names of the objects that query
+// string parameters are usually read from; e.g. params.get("next")
+var redirectParams = regexp.MustCompile(`^(searchParams|params|urlParams|query|qs)$`)
+
+// openRedirectMatcher flags navigation to a URL that comes straight from
+// user input, like location.href = params.get("next"), which is a sign of
+// an open redirect. It's a narrow heuristic rather than taint analysis:
+// the value must either read user input itself, or be a variable that
+// was declared with a value that does.
+func openRedirectMatcher() SecretMatcher {
+
+	return SecretMatcher{Name: "possibleOpenRedirect", Kind: "possibleOpenRedirect", Severity: SeverityLow, Query: "[(assignment_expression) (call_expression)] @matches", Fn: func(n *Node) *Secret {
+		var sink string
+		var value *Node
+
+		switch n.Type() {
+		case "assignment_expression":
+			sink = n.ChildByFieldName("left").Content()
+			if !redirectSinks.Contains(strings.TrimSuffix(sink, ".href")) {
+				return nil
+			}
+			value = n.ChildByFieldName("right")
+
+		case "call_expression":
+			sink = n.ChildByFieldName("function").Content()
+			if !redirectCalls.Contains(sink) {
+				return nil
+			}
+			value = n.ChildByFieldName("arguments").NamedChild(0)
+		}
+
+		if !value.IsValid() {
+			return nil
+		}
+
+		source := redirectSourceOf(value)
+		if source == nil {
+			return nil
+		}
+
+		return &Secret{
+			Kind:     "possibleOpenRedirect",
+			Severity: SeverityLow,
+			Data: map[string]string{
+				"sink":   sink,
+				"source": source.Content(),
+				"value":  value.Content(),
+			},
+		}
+	}}
+}
+
+// redirectSourceOf returns the expression that reads the user input the
+// value starts with, or nil if it doesn't start with any. Only the start
+// matters: "/login?next=" + location.search can't go anywhere but /login.
+// Identifiers are followed back to the value they were declared with.
+func redirectSourceOf(value *Node) *Node {
+	for depth := 0; depth < 3; depth++ {
+		value = urlPrefix(value)
+		if !value.IsValid() {
+			return nil
+		}
+
+		if isRedirectSource(value) {
+			return value
+		}
+
+		if value.Type() != "identifier" {
+			return nil
+		}
+		value = value.declaredValue(value.Content())
+	}
+
+	return nil
+}
+
+// urlPrefix returns the expression that determines the start of a URL; i.e.
+// the leftmost part of a concatenation, or the first substitution in a
+// template string if there's nothing before it. Calls that decode their
+// argument are unwrapped, because they don't change where the URL goes.
+func urlPrefix(n *Node) *Node {
+	for n.IsValid() {
+		switch n.Type() {
+		case "parenthesized_expression":
+			n = n.NamedChild(0)
+
+		case "binary_expression":
+			if n.ChildByFieldName("operator").Content() != "+" {
+				return n
+			}
+			n = n.ChildByFieldName("left")
+
+		case "template_string":
+			first := n.NamedChild(0)
+			if !first.IsValid() || first.Type() != "template_substitution" ||
+				first.node.StartByte() != n.node.StartByte()+1 {
+				return nil
+			}
+			n = first.NamedChild(0)
+
+		case "call_expression":
+			switch n.ChildByFieldName("function").Content() {
+			case "decodeURIComponent", "decodeURI", "unescape", "atob":
+				n = n.ChildByFieldName("arguments").NamedChild(0)
+			default:
+				return n
+			}
+
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// isRedirectSource returns true if an expression reads user input; i.e. it's
+// one of the redirectSources, a parameter read with .get(), or a chain of
+// calls and properties on one of them like location.hash.slice(1). Other
+// expressions (e.g. ternaries and calls that take user input as an argument)
+// aren't looked inside, because what they evaluate to isn't the input.
+func isRedirectSource(n *Node) bool {
+	for n.IsValid() {
+		switch n.Type() {
+		case "member_expression":
+			if redirectSources.Contains(n.Content()) {
+				return true
+			}
+			n = n.ChildByFieldName("object")
+
+		case "call_expression":
+			fn := n.ChildByFieldName("function")
+			if fn.Type() == "member_expression" &&
+				fn.ChildByFieldName("property").Content() == "get" &&
+				isRedirectParams(fn.ChildByFieldName("object")) {
+				return true
+			}
+			n = fn
+
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// isRedirectParams returns true if an expression looks like an object
+// holding query string parameters; e.g. params, url.searchParams,
+// or new URLSearchParams(location.search)
+func isRedirectParams(n *Node) bool {
+	switch n.Type() {
+	case "identifier":
+		return redirectParams.MatchString(n.Content())
+	case "member_expression":
+		return redirectParams.MatchString(n.ChildByFieldName("property").Content())
+	case "new_expression":
+		return n.ChildByFieldName("constructor").Content() == "URLSearchParams"
+	case "parenthesized_expression":
+		return isRedirectParams(n.NamedChild(0))
+	}
+	return false
+}
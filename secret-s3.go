@@ -0,0 +1,74 @@
+package jsluice
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// s3BucketName matches valid S3 bucket names; i.e. 3 to 63 lowercase
+// letters, numbers, dots, and hyphens, starting and ending with a letter
+// or number
+var s3BucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// s3Reference matches references to buckets (and optionally the objects
+// in them) that aren't URLs for HTTP; e.g. s3://bucket/key, and
+// arn:aws:s3:::bucket/key
+var s3Reference = regexp.MustCompile(`^(?:s3://|arn:aws[a-z-]*:s3:::)([^/]+)(?:/(.*))?$`)
+
+// s3BucketMatcher finds references to S3 buckets in s3:// URLs and ARNs.
+// Bucket names aren't secret, but knowing them means the buckets can be
+// tested for misconfigurations like public listing or writes. HTTPS URLs
+// for buckets are found by the URL matchers instead, which set the
+// S3Bucket field of the URL.
+func s3BucketMatcher() SecretMatcher {
+
+	return SecretMatcher{Name: "s3Bucket", Kind: "s3Bucket", Severity: SeverityInfo, Query: "(string) @matches", Fn: func(n *Node) *Secret {
+		str := n.RawString()
+
+		parts := s3Reference.FindStringSubmatch(str)
+		if parts == nil || !s3BucketName.MatchString(parts[1]) {
+			return nil
+		}
+
+		data := map[string]string{
+			"bucket": parts[1],
+			"value":  str,
+		}
+		if parts[2] != "" {
+			data["key"] = parts[2]
+		}
+
+		return &Secret{
+			Kind:     "s3Bucket",
+			Severity: SeverityInfo,
+			Data:     data,
+		}
+	}}
+}
+
+// s3Host matches the hostnames for S3, with the bucket name in the first
+// group for virtual-hosted-style URLs; e.g. bucket.s3.amazonaws.com,
+// bucket.s3.us-west-2.amazonaws.com, bucket.s3-us-west-2.amazonaws.com,
+// and s3.amazonaws.com for path-style URLs
+var s3Host = regexp.MustCompile(`^(?:(.+)\.)?s3(?:[.-][a-z0-9-]+)?\.amazonaws\.com$`)
+
+// s3Bucket returns the name of the bucket that a URL is for, or an empty
+// string if it isn't an S3 URL. The bucket is in the hostname for
+// virtual-hosted-style URLs, or the first part of the path otherwise.
+func s3Bucket(u *url.URL) string {
+	parts := s3Host.FindStringSubmatch(strings.ToLower(u.Hostname()))
+	if parts == nil {
+		return ""
+	}
+
+	bucket := parts[1]
+	if bucket == "" {
+		bucket, _, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	}
+
+	if !s3BucketName.MatchString(bucket) {
+		return ""
+	}
+	return bucket
+}
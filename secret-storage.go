@@ -0,0 +1,56 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// storageObjects are the Web Storage objects that tokens are kept in
+var storageObjects = newSet([]string{
+	"localStorage",
+	"sessionStorage",
+	"window.localStorage",
+	"window.sessionStorage",
+})
+
+// storageTokenKey matches the names of storage keys that usually hold
+// something to do with authentication; e.g. access_token, jwt, or authUser
+var storageTokenKey = regexp.MustCompile(`(?i)token|jwt|auth|bearer|session|credential|api[_-]?key`)
+
+// storageTokenReadMatcher finds reads of auth-related keys from local or
+// session storage, like localStorage.getItem("access_token"). They aren't
+// secrets themselves, but they say where the app expects to find its
+// tokens, which is useful for understanding how it handles authentication.
+func storageTokenReadMatcher() SecretMatcher {
+
+	return SecretMatcher{Name: "storageTokenRead", Kind: "storageTokenRead", Severity: SeverityInfo, Query: "(call_expression) @matches", Fn: func(n *Node) *Secret {
+		fn := n.ChildByFieldName("function")
+		if fn.Type() != "member_expression" || fn.ChildByFieldName("property").Content() != "getItem" {
+			return nil
+		}
+
+		storage := fn.ChildByFieldName("object").Content()
+		if !storageObjects.Contains(storage) {
+			return nil
+		}
+
+		key := n.ChildByFieldName("arguments").NamedChild(0)
+		if !key.IsValid() || key.Type() != "string" {
+			return nil
+		}
+
+		name := key.RawString()
+		if !storageTokenKey.MatchString(name) {
+			return nil
+		}
+
+		return &Secret{
+			Kind:     "storageTokenRead",
+			Severity: SeverityInfo,
+			Data: map[string]string{
+				"storage": strings.TrimPrefix(storage, "window."),
+				"key":     name,
+			},
+		}
+	}}
+}
@@ -0,0 +1,109 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// weakCredentialKey matches the names of keys and variables that usually
+// hold passwords or username:password pairs; e.g. password, DB_PASS, or
+// basicAuth. The word has to end the name, and start it or follow a
+// separator or a camelCase boundary, so that author and compass don't match.
+var weakCredentialKey = regexp.MustCompile(
+	`(^|[_.-])(?i:pass(word|wd|phrase)?|pwd|secret|cred(ential)?s?|auth|login)$|` +
+		`[a-z0-9](Pass(word|wd|phrase)?|Pwd|Secret|Cred(ential)?s?|Auth|Login)$`,
+)
+
+// weakPasswords are passwords that are used for testing, or that are
+// left as defaults, and are always the first thing to try
+var weakPasswords = newSet([]string{
+	"admin",
+	"administrator",
+	"changeme",
+	"default",
+	"guest",
+	"letmein",
+	"passw0rd",
+	"password",
+	"password1",
+	"password123",
+	"p@ssw0rd",
+	"qwerty",
+	"root",
+	"secret",
+	"test",
+	"test123",
+	"testing",
+	"toor",
+	"welcome",
+	"123456",
+	"12345678",
+})
+
+// WeakCredentialMatcher returns a SecretMatcher that finds obvious test
+// or default credentials, like password: "changeme" or auth: "admin:admin",
+// that have been left in a build. It's not included in AllSecretMatchers
+// because it's mostly useful for internal assessments, and can be noisy
+// elsewhere; add it with (*Analyzer).AddSecretMatcher to use it.
+func WeakCredentialMatcher() SecretMatcher {
+
+	return SecretMatcher{Name: "weakCredential", Kind: "weakCredential", Severity: SeverityInfo, Query: "[(pair) (variable_declarator) (assignment_expression)] @matches", Fn: func(n *Node) *Secret {
+		var key, value *Node
+
+		switch n.Type() {
+		case "pair":
+			key = n.ChildByFieldName("key")
+			value = n.ChildByFieldName("value")
+		case "variable_declarator":
+			key = n.ChildByFieldName("name")
+			value = n.ChildByFieldName("value")
+		case "assignment_expression":
+			key = n.ChildByFieldName("left")
+			if key.Type() == "member_expression" {
+				key = key.ChildByFieldName("property")
+			}
+			value = n.ChildByFieldName("right")
+		}
+
+		if !key.IsValid() || !value.IsValid() || value.Type() != "string" {
+			return nil
+		}
+
+		name := key.RawString()
+		if !weakCredentialKey.MatchString(name) {
+			return nil
+		}
+
+		credential := value.RawString()
+		if !isWeakCredential(credential) {
+			return nil
+		}
+
+		match := &Secret{
+			Kind:     "weakCredential",
+			Severity: SeverityInfo,
+			Data: map[string]string{
+				"key":   name,
+				"value": credential,
+			},
+		}
+
+		if parent := n.Parent(); n.Type() == "pair" && parent.Type() == "object" {
+			match.Context = parent.AsObject().AsMap()
+		}
+
+		return match
+	}}
+}
+
+// isWeakCredential returns true if a value is a well-known weak
+// password, or a username:password pair where both parts are
+func isWeakCredential(value string) bool {
+	value = strings.ToLower(value)
+
+	if user, pass, found := strings.Cut(value, ":"); found {
+		return weakPasswords.Contains(user) && weakPasswords.Contains(pass)
+	}
+
+	return weakPasswords.Contains(value)
+}
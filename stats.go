@@ -0,0 +1,111 @@
+package jsluice
+
+import (
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// AnalyzerStats contains information about the parse tree for the
+// source being analyzed. It's mostly useful for debugging; e.g. a
+// large number of parse errors usually means the source isn't
+// JavaScript, or uses syntax the grammar doesn't support, such as
+// TypeScript type annotations.
+type AnalyzerStats struct {
+	// NodeCount is the total number of nodes in the parse tree,
+	// including anonymous nodes such as punctuation
+	NodeCount int `json:"nodeCount"`
+
+	// MaxDepth is the depth of the deepest node in the parse
+	// tree, where the root node has a depth of zero
+	MaxDepth int `json:"maxDepth"`
+
+	// ErrorCount is the number of ERROR nodes in the parse tree
+	ErrorCount int `json:"errorCount"`
+
+	// SourceSize is the size of the source in bytes
+	SourceSize int `json:"sourceSize"`
+}
+
+// Stats returns information about the parse tree for the source being
+// analyzed, such as the number of nodes and parse errors it contains
+func (a *Analyzer) Stats() AnalyzerStats {
+	root := a.RootNode()
+
+	stats := AnalyzerStats{
+		SourceSize: len(root.source),
+	}
+
+	if root.node == nil {
+		return stats
+	}
+
+	root.walk(func(n *sitter.Node, depth int) bool {
+		stats.NodeCount++
+		if n.IsError() {
+			stats.ErrorCount++
+		}
+		if depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+		return true
+	})
+
+	return stats
+}
+
+// HasErrors returns true if the parse tree contains any ERROR or MISSING
+// nodes, which usually means some of the source couldn't be analyzed
+func (a *Analyzer) HasErrors() bool {
+	root := a.RootNode()
+	if root.node == nil {
+		return false
+	}
+	return root.node.HasError()
+}
+
+// ErrorNodes returns the ERROR and MISSING nodes in the parse tree.
+// Nodes inside an ERROR node are not included.
+func (a *Analyzer) ErrorNodes() []*Node {
+	root := a.RootNode()
+	out := make([]*Node, 0)
+
+	if root.node == nil {
+		return out
+	}
+
+	root.walk(func(n *sitter.Node, depth int) bool {
+		if n.IsError() || n.IsMissing() {
+			out = append(out, root.wrap(n))
+			return false
+		}
+
+		// there's no point looking at subtrees without any errors
+		return n.HasError()
+	})
+
+	return out
+}
+
+// walk calls fn for the Node and each of its descendants in depth-first
+// order, along with their depth relative to the Node. The children of a
+// node are skipped if fn returns false. A cursor is used rather than
+// Children() etc because the parse trees for large files can have
+// millions of nodes.
+func (n *Node) walk(fn func(*sitter.Node, int) bool) {
+	cursor := sitter.NewTreeCursor(n.node)
+	defer cursor.Close()
+
+	depth := 0
+	for {
+		if fn(cursor.CurrentNode(), depth) && cursor.GoToFirstChild() {
+			depth++
+			continue
+		}
+
+		for !cursor.GoToNextSibling() {
+			if !cursor.GoToParent() {
+				return
+			}
+			depth--
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package jsluice
+
+import (
+	"testing"
+)
+
+func TestAnalyzerStats(t *testing.T) {
+	source := []byte(`fetch("/api")`)
+	stats := NewAnalyzer(source).Stats()
+
+	// program > expression_statement > call_expression > arguments > string > string_fragment
+	if stats.MaxDepth != 5 {
+		t.Errorf("want max depth of 5; have %d", stats.MaxDepth)
+	}
+
+	if stats.NodeCount < 6 {
+		t.Errorf("want at least 6 nodes; have %d", stats.NodeCount)
+	}
+
+	if stats.ErrorCount != 0 {
+		t.Errorf("want no parse errors; have %d", stats.ErrorCount)
+	}
+
+	if stats.SourceSize != len(source) {
+		t.Errorf("want source size of %d; have %d", len(source), stats.SourceSize)
+	}
+}
+
+func TestAnalyzerStatsErrors(t *testing.T) {
+	// TypeScript type annotations aren't supported by the JavaScript grammar
+	a := NewAnalyzer([]byte(`
+		function get(id: number): Promise<User> {
+			return fetch("/api/users/" + id)
+		}
+	`))
+
+	if a.Stats().ErrorCount == 0 {
+		t.Errorf("want parse errors for TypeScript; have none")
+	}
+}
+
+func TestAnalyzerErrorNodes(t *testing.T) {
+	a := NewAnalyzer([]byte(`fetch("/api")`))
+	if a.HasErrors() {
+		t.Errorf("want no errors for valid JavaScript")
+	}
+	if len(a.ErrorNodes()) != 0 {
+		t.Errorf("want no error nodes for valid JavaScript; have %d", len(a.ErrorNodes()))
+	}
+
+	a = NewAnalyzer([]byte(`
+		const x: number = 1
+		fetch("/api")
+	`))
+	if !a.HasErrors() {
+		t.Errorf("want errors for TypeScript")
+	}
+
+	nodes := a.ErrorNodes()
+	if len(nodes) == 0 {
+		t.Fatalf("want error nodes for TypeScript; have none")
+	}
+	for _, n := range nodes {
+		if n.Type() != "ERROR" && !n.node.IsMissing() {
+			t.Errorf("want ERROR or MISSING node; have %s", n.Type())
+		}
+	}
+
+	// the rest of the file should still be analyzed
+	if len(a.GetURLs()) == 0 {
+		t.Errorf("want URLs from the valid parts of the file; have none")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -114,8 +115,14 @@ func (s *stringLexer) Peek() rune {
 
 // Emit adds a token of the provided type to the stringLexer's
 // internal list of tokens. The start pointer is advanced to the
-// current position.
+// current position. Empty tokens are not added.
 func (s *stringLexer) Emit(t itemType) {
+	// Empty tokens contribute nothing to the output, and would
+	// get in the way of combining adjacent surrogate pairs
+	if s.start == s.pos {
+		return
+	}
+
 	s.items = append(s.items, item{
 		typ: t,
 		val: s.str[s.start:s.pos],
@@ -130,6 +137,14 @@ func (s *stringLexer) Ignore() {
 	s.start = s.pos
 }
 
+// Reset moves both the start and position pointers to the provided
+// position, so that lexing can be retried from that point
+func (s *stringLexer) Reset(pos int) {
+	s.start = pos
+	s.pos = pos
+	s.done = pos >= len(s.str)
+}
+
 // Accept advances the position pointer only if the next rune
 // is in the set of valid runes provided
 func (s *stringLexer) Accept(valid string) bool {
@@ -173,12 +188,46 @@ func (s *stringLexer) AcceptRun(valid string) {
 // called after all the input has been processed.
 func (s *stringLexer) String() string {
 	out := &strings.Builder{}
-	for _, i := range s.items {
-		out.WriteString(i.String())
+	for n := 0; n < len(s.items); n++ {
+		// Characters outside of the basic multilingual plane (e.g. emoji)
+		// are escaped as a UTF-16 surrogate pair; e.g. \uD83D\uDE00, and
+		// the two halves must be combined to get the actual character
+		if n+1 < len(s.items) {
+			if r, ok := surrogatePair(s.items[n], s.items[n+1]); ok {
+				out.WriteRune(r)
+				n++
+				continue
+			}
+		}
+		out.WriteString(s.items[n].String())
 	}
 	return out.String()
 }
 
+// surrogatePair combines two unicode escape items into a single rune
+// if they are the high and low halves of a UTF-16 surrogate pair
+func surrogatePair(high, low item) (rune, bool) {
+	if high.typ != itemUnicodeEscape || low.typ != itemUnicodeEscape {
+		return 0, false
+	}
+
+	h, err := strconv.ParseInt(high.val, 16, 0)
+	if err != nil {
+		return 0, false
+	}
+
+	l, err := strconv.ParseInt(low.val, 16, 0)
+	if err != nil {
+		return 0, false
+	}
+
+	r := utf16.DecodeRune(rune(h), rune(l))
+	if r == unicode.ReplacementChar {
+		return 0, false
+	}
+	return r, true
+}
+
 // DecodeString accepts a raw string as it might be found in some
 // JavaScript source code, and converts any escape sequences. E.g:
 //   foo\x3dbar -> foo=bar // Hex escapes
@@ -204,6 +253,11 @@ func DecodeString(in string) string {
 		l.Next()
 		l.Ignore()
 
+		// Truncated hex and unicode escapes (e.g. \x3 or \u3d) are
+		// treated like any other unrecognised escape: the backslash
+		// is dropped and everything after it is kept as-is
+		escape := l.pos
+
 		switch l.Next() {
 		case 'b', 'f', 'n', 'r', 't', 'v', '\'', '"', '\\':
 			l.Emit(itemSingleEscape)
@@ -221,9 +275,11 @@ func DecodeString(in string) string {
 			l.Ignore()
 
 			// Exactly 2 hex digits
-			if l.AcceptN(validHex, 2) {
-				l.Emit(itemHexEscape)
+			if !l.AcceptN(validHex, 2) {
+				l.Reset(escape)
+				continue
 			}
+			l.Emit(itemHexEscape)
 		case 'u':
 			// ignore the u
 			l.Ignore()
@@ -236,12 +292,15 @@ func DecodeString(in string) string {
 				if l.Accept("}") {
 					l.Ignore()
 				}
+				continue
 			}
 
 			// e.g. \u003d
-			if l.AcceptN(validHex, 4) {
-				l.Emit(itemUnicodeEscape)
+			if !l.AcceptN(validHex, 4) {
+				l.Reset(escape)
+				continue
 			}
+			l.Emit(itemUnicodeEscape)
 
 		}
 	}
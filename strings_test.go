@@ -34,10 +34,27 @@ func TestStringDecode(t *testing.T) {
 		{`"\075\x3d"`, `==`},
 		{`"\u{00000003d}\x3d"`, `==`},
 
+		// surrogate pairs
+		{`"\uD83D\uDE00"`, "\U0001F600"},
+		{`"smile\ud83d\ude00!"`, "smile\U0001F600!"},
+		{`"\uD834\uDD1E"`, "\U0001D11E"},
+		{`"\uD83D\uDE00\uD83D\uDE01"`, "\U0001F600\U0001F601"},
+		{`"\u{1F600}"`, "\U0001F600"},
+
 		// Invalid
 		{`"\poo"`, `poo`},
 		{`"\u{0003doops"`, `=oops`},
 
+		// Truncated
+		{`"foo\u3dzz"`, `foou3dzz`},
+		{`"foo\u03dzbar"`, `foou03dzbar`},
+		{`"foo\u3d"`, `foou3d`},
+		{`"foo\u"`, `foou`},
+		{`"foo\u\x3d"`, `foou=`},
+		{`"foo\x3"`, `foox3`},
+		{`"foo\x3zbar"`, `foox3zbar`},
+		{`"\u{3d}0041"`, `=0041`},
+
 		// real-world
 		{`"/help/doc/user_ed.jsp?loc\x3dhelp\x26target\x3d"`, "/help/doc/user_ed.jsp?loc=help&target="},
 	}
@@ -1,6 +1,7 @@
 package jsluice
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -15,6 +16,10 @@ import (
 //   "prefix" + someVar + "suffix"
 // Would become:
 //   prefixEXPRsuffix
+//
+// New Analyzers copy the value of ExpressionPlaceholder when they
+// are created. Set (*Analyzer).ExpressionPlaceholder instead to use
+// different placeholders for different Analyzers.
 var ExpressionPlaceholder = "EXPR"
 
 // Node is a wrapper around a tree-sitter node. It serves as
@@ -25,6 +30,12 @@ type Node struct {
 	node        *sitter.Node
 	source      []byte
 	captureName string
+
+	// the Analyzer the Node belongs to, if there is one
+	analyzer *Analyzer
+
+	// the grammar the Node was parsed with; nil means JavaScript
+	lang *sitter.Language
 }
 
 // NewNode creates a new Node for the provided tree-sitter
@@ -38,6 +49,118 @@ func NewNode(n *sitter.Node, source []byte) *Node {
 	}
 }
 
+// wrap returns a new Node for a tree-sitter node from the same
+// tree as n, carrying over the source and Analyzer
+func (n *Node) wrap(sn *sitter.Node) *Node {
+	return &Node{
+		node:     sn,
+		source:   n.source,
+		analyzer: n.analyzer,
+		lang:     n.lang,
+	}
+}
+
+// Lang returns the tree-sitter grammar that the Node was parsed with,
+// which queries run against the Node have to be compiled for
+func (n *Node) Lang() *sitter.Language {
+	if n == nil || n.lang == nil {
+		return javascript.GetLanguage()
+	}
+	return n.lang
+}
+
+// placeholder returns the expression placeholder for the Node's
+// Analyzer, falling back to the global ExpressionPlaceholder
+func (n *Node) placeholder() string {
+	if n.analyzer != nil {
+		return n.analyzer.ExpressionPlaceholder
+	}
+	return ExpressionPlaceholder
+}
+
+// maxURLStringLength returns the MaxURLStringLength for the Node's
+// Analyzer, falling back to DefaultMaxURLStringLength
+func (n *Node) maxURLStringLength() int {
+	if n.analyzer != nil {
+		return n.analyzer.MaxURLStringLength
+	}
+	return DefaultMaxURLStringLength
+}
+
+// matchTrailingStrings returns true if the Node's Analyzer has
+// the MatchTrailingStrings option set
+func (n *Node) matchTrailingStrings() bool {
+	return n.analyzer != nil && n.analyzer.MatchTrailingStrings
+}
+
+// A Position is a location in the source code. Lines and
+// columns both start at 1, and columns are counted in bytes.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// comparePositions returns -1 if a is before b in the source, 1 if it's
+// after, and 0 if they're the same. Results without a position (e.g. from
+// custom matchers that don't set one) go after those with one.
+func comparePositions(a, b *Position) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	case a.Line != b.Line:
+		if a.Line < b.Line {
+			return -1
+		}
+		return 1
+	case a.Column != b.Column:
+		if a.Column < b.Column {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Position returns the location of the start of the Node
+// in the source code
+func (n *Node) Position() Position {
+	if !n.IsValid() {
+		return Position{}
+	}
+
+	p := n.node.StartPoint()
+	return Position{
+		Line:   int(p.Row) + 1,
+		Column: int(p.Column) + 1,
+	}
+}
+
+// maxNodeStringContent is the maximum number of characters of
+// content included in the output of Node.String
+const maxNodeStringContent = 40
+
+// String returns a short description of the Node for debugging,
+// e.g. <Node type=call_expression line=42 "fetch(\"/api\", {...">
+// Whitespace in the content is collapsed, and long content is
+// truncated.
+func (n *Node) String() string {
+	if !n.IsValid() {
+		return "<Node invalid>"
+	}
+
+	content := []rune(strings.Join(strings.Fields(n.Content()), " "))
+	if len(content) > maxNodeStringContent {
+		content = append(content[:maxNodeStringContent], []rune("...")...)
+	}
+
+	return fmt.Sprintf("<Node type=%s line=%d %q>", n.Type(), n.Position().Line, string(content))
+}
+
 // AsObject returns a Node as jsluice's internal object type,
 // to allow the fetching of keys etc
 func (n *Node) AsObject() Object {
@@ -68,7 +191,7 @@ func (n *Node) ChildByFieldName(name string) *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.ChildByFieldName(name), n.source)
+	return n.wrap(n.node.ChildByFieldName(name))
 }
 
 // Child returns the child Node at the provided index
@@ -76,7 +199,7 @@ func (n *Node) Child(index int) *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.Child(index), n.source)
+	return n.wrap(n.node.Child(index))
 }
 
 // NamedChild returns the 'named' child Node at the provided
@@ -89,7 +212,7 @@ func (n *Node) NamedChild(index int) *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.NamedChild(index), n.source)
+	return n.wrap(n.node.NamedChild(index))
 }
 
 // ChildCount returns the number of children a node has
@@ -138,7 +261,7 @@ func (n *Node) NextSibling() *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.NextSibling(), n.source)
+	return n.wrap(n.node.NextSibling())
 }
 
 // NextNamedSibling returns the next named sibling in the tree
@@ -146,7 +269,7 @@ func (n *Node) NextNamedSibling() *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.NextNamedSibling(), n.source)
+	return n.wrap(n.node.NextNamedSibling())
 }
 
 // PrevSibling returns the previous sibling in the tree
@@ -154,7 +277,7 @@ func (n *Node) PrevSibling() *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.PrevSibling(), n.source)
+	return n.wrap(n.node.PrevSibling())
 }
 
 // PrevNamedSibling returns the previous named sibling in the tree
@@ -162,7 +285,7 @@ func (n *Node) PrevNamedSibling() *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.PrevNamedSibling(), n.source)
+	return n.wrap(n.node.PrevNamedSibling())
 }
 
 // CollapsedString takes a node representing a URL and attempts to make it
@@ -175,22 +298,31 @@ func (n *Node) PrevNamedSibling() *Node {
 //
 //  ./upload.php?profile=EXPR&show=EXPR
 //
-// The value of ExpressionPlaceholder is used as a placeholder, defaulting to 'EXPR'
+// The value of the Analyzer's ExpressionPlaceholder is used as a placeholder,
+// defaulting to the global ExpressionPlaceholder; 'EXPR' unless it's been changed.
 func (n *Node) CollapsedString() string {
 	if !n.IsValid() {
 		return ""
 	}
 	switch n.Type() {
 	case "binary_expression":
-		return fmt.Sprintf(
-			"%s%s",
-			n.ChildByFieldName("left").CollapsedString(),
-			n.ChildByFieldName("right").CollapsedString(),
-		)
+		// The page's origin often starts a URL (e.g. location.origin + "/api"),
+		// and leaving it out gives a usable root-relative path instead of EXPR/api
+		var b strings.Builder
+		for _, part := range trimOrigin(concatOperands(n)) {
+			b.WriteString(part.CollapsedString())
+		}
+		return b.String()
 	case "string":
 		return n.RawString()
 	default:
-		return ExpressionPlaceholder
+		if arg := n.uriEncodedArg(); arg != nil {
+			return arg.CollapsedString()
+		}
+		if v, exists := n.symbol(); exists {
+			return v
+		}
+		return n.placeholder()
 	}
 }
 
@@ -252,6 +384,12 @@ func (n *Node) AsGoType() any {
 	}
 }
 
+// JSON returns the JSON representation of the Node's
+// value, as returned by AsGoType
+func (n *Node) JSON() ([]byte, error) {
+	return json.Marshal(n.AsGoType())
+}
+
 // AsMap returns a representation of the Node as a map[string]any
 func (n *Node) AsMap() map[string]any {
 	if n.Type() != "object" {
@@ -325,7 +463,7 @@ func (n *Node) Parent() *Node {
 	if !n.IsValid() {
 		return nil
 	}
-	return NewNode(n.node.Parent(), n.source)
+	return n.wrap(n.node.Parent())
 }
 
 // IsNamed returns true if the underlying node is named
@@ -342,7 +480,7 @@ func (n *Node) ForEachChild(fn func(*Node)) {
 	it := sitter.NewIterator(n.node, sitter.DFSMode)
 
 	it.ForEach(func(sn *sitter.Node) error {
-		fn(NewNode(sn, n.source))
+		fn(n.wrap(sn))
 		return nil
 	})
 }
@@ -353,7 +491,7 @@ func (n *Node) ForEachNamedChild(fn func(*Node)) {
 	it := sitter.NewNamedIterator(n.node, sitter.DFSMode)
 
 	it.ForEach(func(sn *sitter.Node) error {
-		fn(NewNode(sn, n.source))
+		fn(n.wrap(sn))
 		return nil
 	})
 }
@@ -431,7 +569,7 @@ func (n *Node) QueryMulti(query string, fn func(QueryResult)) {
 	}
 	q, err := sitter.NewQuery(
 		[]byte(query),
-		javascript.GetLanguage(),
+		n.Lang(),
 	)
 	if err != nil {
 		return
@@ -453,7 +591,7 @@ func (n *Node) QueryMulti(query string, fn func(QueryResult)) {
 		qr := NewQueryResult()
 
 		for _, capture := range match.Captures {
-			node := NewNode(capture.Node, n.source)
+			node := n.wrap(capture.Node)
 			node.captureName = q.CaptureNameForId(capture.Index)
 			qr.Add(node)
 		}
@@ -464,6 +602,44 @@ func (n *Node) QueryMulti(query string, fn func(QueryResult)) {
 	}
 }
 
+// QueryOne runs a tree-sitter query against the Node and returns the
+// first captured Node, or nil if nothing matched. Unlike Query, it stops
+// as soon as a match is found.
+func (n *Node) QueryOne(query string) *Node {
+	if !n.IsValid() {
+		return nil
+	}
+	q, err := sitter.NewQuery(
+		[]byte(query),
+		n.Lang(),
+	)
+	if err != nil {
+		return nil
+	}
+
+	qc := sitter.NewQueryCursor()
+	defer qc.Close()
+
+	qc.Exec(q, n.node)
+
+	for {
+		match, exists := qc.NextMatch()
+		if !exists || match == nil {
+			return nil
+		}
+
+		match = qc.FilterPredicates(match, n.source)
+		if len(match.Captures) == 0 {
+			continue
+		}
+
+		capture := match.Captures[0]
+		node := n.wrap(capture.Node)
+		node.captureName = q.CaptureNameForId(capture.Index)
+		return node
+	}
+}
+
 // IsStringy returns true if a Node is a string
 // or is an expression starting with a string
 // (e.g. a string concatenation expression).
@@ -472,6 +648,30 @@ func (n *Node) IsStringy() bool {
 		return true
 	}
 
+	// Constants declared in other files analyzed by a MultiAnalyzer
+	// can be used like strings; e.g. fetch(baseURL + "/users")
+	if _, exists := n.symbol(); exists {
+		return true
+	}
+	if n.Type() == "binary_expression" {
+		if _, exists := concatOperands(n)[0].symbol(); exists {
+			return true
+		}
+	}
+
+	// e.g. fetch(location.origin + "/users")
+	if n.Type() == "binary_expression" {
+		parts := concatOperands(n)
+		if trimmed := trimOrigin(parts); len(trimmed) < len(parts) && trimmed[0].IsStringy() {
+			return true
+		}
+	}
+
+	// e.g. fetch(encodeURI("/search?q=" + q))
+	if n.uriEncodedArg() != nil {
+		return true
+	}
+
 	c := n.Content()
 	if len(c) == 0 {
 		return false
@@ -485,15 +685,121 @@ func (n *Node) IsStringy() bool {
 	}
 }
 
+// originExpressions refer to the current page's origin, or its host
+var originExpressions = newSet([]string{
+	"location.origin",
+	"location.host",
+	"window.location.origin",
+	"window.location.host",
+	"document.location.origin",
+	"document.location.host",
+	"self.location.origin",
+	"self.location.host",
+	"window.origin",
+	"self.origin",
+})
+
+// protocolExpressions refer to the current page's protocol (e.g. https:)
+var protocolExpressions = newSet([]string{
+	"location.protocol",
+	"window.location.protocol",
+	"document.location.protocol",
+})
+
+// concatOperands returns the operands of a binary expression, including
+// the operands of any binary expressions that it's made up of, in order
+func concatOperands(n *Node) []*Node {
+	if n.Type() != "binary_expression" {
+		return []*Node{n}
+	}
+
+	return append(
+		concatOperands(n.ChildByFieldName("left")),
+		concatOperands(n.ChildByFieldName("right"))...,
+	)
+}
+
+// trimOrigin removes the operands from the start of a concatenation that
+// make up the page's origin; i.e. location.origin or location.host, or
+// location.protocol + "//" + location.host. Nothing is removed if that
+// would leave nothing behind.
+func trimOrigin(parts []*Node) []*Node {
+	if len(parts) > 1 && originExpressions.Contains(parts[0].Content()) {
+		return parts[1:]
+	}
+
+	if len(parts) > 3 && protocolExpressions.Contains(parts[0].Content()) &&
+		parts[1].Type() == "string" && parts[1].RawString() == "//" &&
+		originExpressions.Contains(parts[2].Content()) {
+		return parts[3:]
+	}
+
+	return parts
+}
+
+// uriEncodedArg returns the argument of a call to encodeURI or
+// encodeURIComponent if it is stringy, so that the URL being encoded
+// isn't hidden by the call. Otherwise nil is returned.
+func (n *Node) uriEncodedArg() *Node {
+	if n.Type() != "call_expression" {
+		return nil
+	}
+
+	switch n.ChildByFieldName("function").Content() {
+	case "encodeURI", "encodeURIComponent":
+	default:
+		return nil
+	}
+
+	arg := n.ChildByFieldName("arguments").NamedChild(0)
+	if !arg.IsValid() || !arg.IsStringy() {
+		return nil
+	}
+	return arg
+}
+
+// StringyAlternatives returns the stringy values that a Node could
+// evaluate to. For a ternary expression (e.g. cond ? "/a" : "/b") that's
+// each of its branches that is stringy, including the branches of any
+// nested ternaries. For other stringy Nodes it's just the Node itself.
+func (n *Node) StringyAlternatives() []*Node {
+	out := make([]*Node, 0)
+
+	switch n.Type() {
+	case "parenthesized_expression":
+		return n.NamedChild(0).StringyAlternatives()
+	case "ternary_expression":
+		out = append(out, n.ChildByFieldName("consequence").StringyAlternatives()...)
+		out = append(out, n.ChildByFieldName("alternative").StringyAlternatives()...)
+	default:
+		if n.IsStringy() {
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
+
 // CaptureName returns the name given to a node in a
 // query if one exists, and an empty string otherwise
 func (n *Node) CaptureName() string {
 	return n.captureName
 }
 
-// dequote removes surround quotes from the provided string
+// dequote removes surrounding quotes from the provided string. Only
+// a matching pair is removed, so quotes that are part of the string
+// itself (e.g. "default-src 'self'") are left alone.
 func dequote(in string) string {
-	return strings.Trim(in, "'\"`")
+	if len(in) < 2 {
+		return in
+	}
+
+	first, last := in[0], in[len(in)-1]
+	if first != last || !strings.ContainsRune("'\"`", rune(first)) {
+		return in
+	}
+
+	return in[1 : len(in)-1]
 }
 
 // content returns the source for the provided tree-sitter
@@ -514,18 +820,40 @@ func PrintTree(source []byte) string {
 	tree := parser.Parse(nil, source)
 	root := tree.RootNode()
 
-	return getTree(root, source)
+	return getTree(root, source, false)
+}
+
+// PrintTreeWithRanges is like PrintTree, but each node is prefixed with
+// its byte range in the source, and its index amongst the named children
+// of its parent (i.e. the index to pass to NamedChild). E.g:
+//
+//	[12-34] #1 value: object
+//
+// That makes it easier to see exactly which part of the source a node
+// covers when writing queries.
+func PrintTreeWithRanges(source []byte) string {
+	parser := sitter.NewParser()
+	parser.SetLanguage(javascript.GetLanguage())
+
+	tree := parser.Parse(nil, source)
+	root := tree.RootNode()
+
+	return getTree(root, source, true)
 }
 
 // getTree does the actual heavy lifting and recursion for PrintTree
 // TODO: provide a way to print the tree as a JSON object?
-func getTree(n *sitter.Node, source []byte) string {
+func getTree(n *sitter.Node, source []byte, ranges bool) string {
 
 	out := &strings.Builder{}
 
 	c := sitter.NewTreeCursor(n)
 	defer c.Close()
 
+	// the number of named nodes seen so far at each depth,
+	// which gives us the named child index for each node
+	indices := []int{0}
+
 	// walkies
 	depth := 0
 	recurse := true
@@ -536,17 +864,30 @@ func getTree(n *sitter.Node, source []byte) string {
 				fieldName += ": "
 			}
 
+			prefix := ""
+			if ranges {
+				prefix = fmt.Sprintf("[%d-%d] ", c.CurrentNode().StartByte(), c.CurrentNode().EndByte())
+				if depth > 0 {
+					prefix += fmt.Sprintf("#%d ", indices[depth])
+				}
+			}
+			indices[depth]++
+
 			contentStr := ""
 			if c.CurrentNode().ChildCount() == 0 || c.CurrentNode().Type() == "string" {
 				contentStr = fmt.Sprintf(" (%s)", content(c.CurrentNode(), source))
 			}
-			fmt.Fprintf(out, "%s%s%s%s\n", strings.Repeat("  ", depth), fieldName, c.CurrentNode().Type(), contentStr)
+			fmt.Fprintf(out, "%s%s%s%s%s\n", strings.Repeat("  ", depth), prefix, fieldName, c.CurrentNode().Type(), contentStr)
 		}
 
 		// descend into the tree
 		if recurse && c.GoToFirstChild() {
 			recurse = true
 			depth++
+			if len(indices) <= depth {
+				indices = append(indices, 0)
+			}
+			indices[depth] = 0
 			continue
 		}
 
@@ -2,6 +2,7 @@ package jsluice
 
 import (
 	"strconv"
+	"strings"
 	"testing"
 
 	sitter "github.com/smacker/go-tree-sitter"
@@ -16,6 +17,14 @@ func TestCollapsedString(t *testing.T) {
 		{[]byte(`"./login.php?redirect="+url`), "./login.php?redirect=EXPR"},
 		{[]byte(`'/path/'+['one', 'two', 'three'].join('/')`), "/path/EXPR"},
 		{[]byte(`someVar`), "EXPR"},
+		{[]byte(`encodeURI("/search?q=" + q)`), "/search?q=EXPR"},
+		{[]byte(`"/users/" + encodeURIComponent("a b") + "/posts"`), "/users/a b/posts"},
+		{[]byte(`"/users/" + encodeURIComponent(name)`), "/users/EXPR"},
+		{[]byte(`escape("/not/uri/encoded")`), "EXPR"},
+		{[]byte(`location.origin + "/api/users"`), "/api/users"},
+		{[]byte(`window.location.protocol + "//" + window.location.host + "/api?id=" + id`), "/api?id=EXPR"},
+		{[]byte(`"https://" + location.host + "/api"`), "https://EXPR/api"},
+		{[]byte(`location.origin + path`), "EXPR"},
 	}
 
 	parser := sitter.NewParser()
@@ -43,3 +52,170 @@ func TestCollapsedString(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeJSON(t *testing.T) {
+	cases := []struct {
+		JS       []byte
+		Expected string
+	}{
+		{[]byte(`({name: "foo\x3dbar", "count": 12, ok: true, none: null})`), `{"count":12,"name":"foo=bar","none":null,"ok":true}`},
+		{[]byte(`[1, 2.5, "three", [false]]`), `[1,2.5,"three",[false]]`},
+		{[]byte(`"just a string"`), `"just a string"`},
+	}
+
+	for _, c := range cases {
+		a := NewAnalyzer(c.JS)
+
+		var n *Node
+		a.Query("[(object) (array) (string)] @m", func(m *Node) {
+			if n == nil {
+				n = m
+			}
+		})
+
+		actual, err := n.JSON()
+		if err != nil {
+			t.Fatalf("want nil error for JSON() on %s; have %s", c.JS, err)
+		}
+
+		if string(actual) != c.Expected {
+			t.Errorf("want %s for JSON() on %s; have %s", c.Expected, c.JS, actual)
+		}
+	}
+}
+
+func TestDequote(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected string
+	}{
+		{`"/api"`, `/api`},
+		{`'/api'`, `/api`},
+		{"`/api`", `/api`},
+		{`"default-src 'self'"`, `default-src 'self'`},
+		{`'"quoted"'`, `"quoted"`},
+		{`""`, ``},
+		{`"`, `"`},
+		{`notquoted`, `notquoted`},
+	}
+
+	for _, c := range cases {
+		actual := dequote(c.in)
+		if actual != c.expected {
+			t.Errorf("want %s for dequote(%s); have %s", c.expected, c.in, actual)
+		}
+	}
+}
+
+func TestNodePosition(t *testing.T) {
+	a := NewAnalyzer([]byte("var x = 1\nfunction f() {\n  fetch(\"/api\")\n}\n"))
+
+	var n *Node
+	a.Query("(string) @m", func(m *Node) {
+		n = m
+	})
+
+	expected := Position{Line: 3, Column: 9}
+	if n.Position() != expected {
+		t.Errorf("want %+v for position of string; have %+v", expected, n.Position())
+	}
+
+	var invalid *Node
+	if invalid.Position() != (Position{}) {
+		t.Errorf("want zero Position for invalid node; have %+v", invalid.Position())
+	}
+}
+
+func TestPrintTreeWithRanges(t *testing.T) {
+	source := []byte(`x = {a: "b"}`)
+	tree := PrintTreeWithRanges(source)
+
+	expected := []string{
+		`[0-12] program`,
+		`  [0-12] #0 expression_statement`,
+		`    [0-12] #0 assignment_expression`,
+		`      [0-1] #0 left: identifier (x)`,
+		`      [4-12] #1 right: object`,
+		`        [5-11] #0 pair`,
+		`          [5-6] #0 key: property_identifier (a)`,
+		`          [8-11] #1 value: string ("b")`,
+	}
+
+	lines := strings.Split(tree, "\n")
+	for i, want := range expected {
+		if i >= len(lines) {
+			t.Fatalf("want line %d to be %q; have no such line", i, want)
+		}
+		if lines[i] != want {
+			t.Errorf("want line %d to be %q; have %q", i, want, lines[i])
+		}
+	}
+}
+
+func TestNodeString(t *testing.T) {
+	a := NewAnalyzer([]byte("var x = 1\nfetch(\"/api/users\", {\n  method: \"POST\",\n  body: JSON.stringify(data)\n})\n"))
+
+	call := a.RootNode().QueryOne("(call_expression) @m")
+
+	expected := `<Node type=call_expression line=2 "fetch(\"/api/users\", { method: \"POST\", bo...">`
+	if call.String() != expected {
+		t.Errorf("want %s for String(); have %s", expected, call.String())
+	}
+
+	var invalid *Node
+	if invalid.String() != "<Node invalid>" {
+		t.Errorf("want <Node invalid> for String() on invalid node; have %s", invalid.String())
+	}
+}
+
+func TestNodeQueryOne(t *testing.T) {
+	a := NewAnalyzer([]byte(`fetch("/a"); xhr.open("GET", "/b"); fetch("/c")`))
+	root := a.RootNode()
+
+	str := root.QueryOne("(string) @str")
+	if str.RawString() != "/a" || str.CaptureName() != "str" {
+		t.Errorf("want first string /a captured as str; have %s (%s)", str.RawString(), str.CaptureName())
+	}
+
+	open := root.QueryOne(`
+		(call_expression
+			function: (member_expression property: (property_identifier) @prop)
+			(#eq? @prop "open")
+		) @call
+	`)
+	if open.Content() != `xhr.open("GET", "/b")` {
+		t.Errorf("want xhr.open call; have %s", open)
+	}
+
+	if n := root.QueryOne("(regex) @re"); n != nil {
+		t.Errorf("want nil for query with no matches; have %s", n)
+	}
+
+	if n := root.QueryOne("(not a valid query"); n != nil {
+		t.Errorf("want nil for invalid query; have %s", n)
+	}
+}
+
+func TestNodeLang(t *testing.T) {
+	a := NewAnalyzer([]byte(`fetch("/api")`))
+
+	if a.Lang() == nil {
+		t.Fatalf("want a language for the analyzer; have nil")
+	}
+
+	// Nodes found by queries have to carry the language, so
+	// that queries run against them are compiled for it too
+	call := a.RootNode().QueryOne("(call_expression) @call")
+	if call.Lang() != a.Lang() {
+		t.Errorf("want queried node to have the analyzer's language")
+	}
+
+	if call.QueryOne("(string) @s").RawString() != "/api" {
+		t.Errorf("want /api from a query against a queried node")
+	}
+
+	// Nodes that weren't created by an Analyzer are JavaScript
+	if (&Node{}).Lang().SymbolCount() != javascript.GetLanguage().SymbolCount() {
+		t.Errorf("want JavaScript for a node without a language")
+	}
+}
@@ -0,0 +1,110 @@
+package jsluice
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// FirebaseDatabase holds the details of a Firebase Realtime Database or
+// Firestore URL. Databases with rules that allow unauthenticated reads or
+// writes are a common finding, so they're worth checking for directly.
+type FirebaseDatabase struct {
+	// either realtime or firestore
+	Service string `json:"service"`
+
+	// the Firebase project ID, if it can be worked out from the URL
+	Project string `json:"project,omitempty"`
+
+	// the name of the database; e.g. my-app-default-rtdb or (default)
+	Database string `json:"database,omitempty"`
+}
+
+// firestorePath matches the project and database in the path of Firestore
+// REST API URLs; e.g. /v1/projects/my-app/databases/(default)/documents
+var firestorePath = regexp.MustCompile(`^/v1[a-z0-9]*/projects/([^/]+)(?:/databases/([^/]+))?`)
+
+// firebaseDatabase returns the details of a Firebase database URL, or nil
+// if the URL isn't for one. Realtime Database URLs are one of:
+//
+//	https://<database>.firebaseio.com
+//	https://<database>.<region>.firebasedatabase.app
+//
+// And Firestore URLs are for the REST API:
+//
+//	https://firestore.googleapis.com/v1/projects/<project>/databases/<database>/...
+func firebaseDatabase(u *url.URL) *FirebaseDatabase {
+	host := strings.ToLower(u.Hostname())
+
+	switch {
+	case strings.HasSuffix(host, ".firebaseio.com"),
+		strings.HasSuffix(host, ".firebasedatabase.app"):
+		database, _, _ := strings.Cut(host, ".")
+
+		// The default instance for a project is named after it, with
+		// a -default-rtdb suffix for projects created since 2020
+		return &FirebaseDatabase{
+			Service:  "realtime",
+			Project:  strings.TrimSuffix(database, "-default-rtdb"),
+			Database: database,
+		}
+
+	case host == "firestore.googleapis.com":
+		path := u.EscapedPath()
+		if unescaped, err := url.PathUnescape(path); err == nil {
+			path = unescaped
+		}
+
+		parts := firestorePath.FindStringSubmatch(path)
+		if parts == nil {
+			return &FirebaseDatabase{Service: "firestore"}
+		}
+		return &FirebaseDatabase{
+			Service:  "firestore",
+			Project:  parts[1],
+			Database: parts[2],
+		}
+
+	default:
+		return nil
+	}
+}
+
+func matchFirebase() URLMatcher {
+
+	return URLMatcher{Name: "firebase", Type: "string", Fn: func(n *Node) *URL {
+		// Firestore URLs have (default) as the database name, which
+		// means they're skipped by the string literal matcher:
+		//   "https://firestore.googleapis.com/v1/projects/my-app/databases/(default)/documents"
+		//   databaseURL: "https://my-app-default-rtdb.firebaseio.com"
+		raw := n.RawString()
+
+		u, err := url.Parse(raw)
+		if err != nil || firebaseDatabase(u) == nil {
+			return nil
+		}
+
+		match := &URL{
+			URL:    raw,
+			RawURL: n.Content(),
+			Type:   "firebase",
+			Source: n.Content(),
+		}
+
+		// When the URL is the databaseURL in a Firebase config object, the
+		// whole config is used as the source, so that the database can be
+		// tied back to the project and API key it's used with
+		pair := n.Parent()
+		if pair.Type() != "pair" || pair.ChildByFieldName("key").RawString() != "databaseURL" {
+			return match
+		}
+
+		config := pair.Parent()
+		if config.Type() == "object" && isFirebaseConfig(config.AsObject()) {
+			match.Type = "firebaseConfig"
+			match.Source = config.Content()
+		}
+
+		return match
+	}}
+}
@@ -0,0 +1,66 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// comparedProperties are the (lowercased) names of properties that hold
+// the URL or path of a request
+var comparedProperties = newSet([]string{
+	"url",
+	"path",
+	"pathname",
+	"endpoint",
+	"originalurl",
+})
+
+// relativePath matches relative paths with more than one segment; e.g.
+// api/user/list. They fail MaybeURL because they could be anything in
+// general, but not when they're compared to a request's URL.
+var relativePath = regexp.MustCompile(`^[\w.-]+(/[\w.-]+)+/?$`)
+
+func matchComparisons() URLMatcher {
+
+	return URLMatcher{Name: "comparison", Type: "binary_expression", Fn: func(n *Node) *URL {
+		// Interceptors and routers often check which endpoint a request is
+		// for, and that can be the only place the endpoint appears:
+		//   if (e.url === "api/user/login") { ... }
+		//   if ("/admin/users" == req.path) { ... }
+		switch n.ChildByFieldName("operator").Content() {
+		case "==", "===", "!=", "!==":
+		default:
+			return nil
+		}
+
+		left := n.ChildByFieldName("left")
+		right := n.ChildByFieldName("right")
+
+		str, other := left, right
+		if str.Type() != "string" {
+			str, other = right, left
+		}
+		if str.Type() != "string" || other.Type() != "member_expression" {
+			return nil
+		}
+
+		property := other.ChildByFieldName("property").Content()
+		if !comparedProperties.Contains(strings.ToLower(property)) {
+			n.debugf("%s is not a URL property", property)
+			return nil
+		}
+
+		value := str.RawString()
+		if !MaybeURL(value) && !relativePath.MatchString(value) {
+			n.debugf("compared string doesn't look like a path")
+			return nil
+		}
+
+		return &URL{
+			URL:    value,
+			RawURL: str.Content(),
+			Type:   "comparison",
+			Source: n.Content(),
+		}
+	}}
+}
@@ -0,0 +1,97 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// cspDirectiveName matches the names of CSP directives
+var cspDirectiveName = regexp.MustCompile(`^[a-z]+(-[a-z]+)*$`)
+
+// cspSourceDirectives are the CSP directives that take a list of sources,
+// other than the fetch directives, which all end in -src
+var cspSourceDirectives = newSet([]string{
+	"base-uri",
+	"form-action",
+	"frame-ancestors",
+	"report-uri",
+})
+
+// cspKeyword matches sources that aren't URLs or hosts; i.e. keywords like
+// 'self', nonces, and hashes, and sources that are just a scheme (data:)
+var cspKeyword = regexp.MustCompile(`^'.*'$|^[a-zA-Z][a-zA-Z0-9+.-]*:$|^\*$`)
+
+func matchCSP() URLMatcher {
+
+	return URLMatcher{Name: "csp", Type: "string", MultiFn: func(n *Node) []*URL {
+		// Content Security Policies list the origins that a page is allowed
+		// to load things from, which says a lot about what it integrates with:
+		//   "default-src 'self'; script-src 'self' https://cdn.example.com; connect-src *.example.com"
+		raw := n.RawString()
+		if !strings.Contains(raw, "-src ") && !strings.Contains(raw, "-uri ") {
+			return nil
+		}
+
+		directives, ok := parseCSP(raw)
+		if !ok {
+			n.debugf("not a content security policy")
+			return nil
+		}
+
+		out := make([]*URL, 0)
+		for _, d := range directives {
+			for _, source := range d.sources {
+				out = append(out, &URL{
+					URL:       source,
+					Type:      "cspSource",
+					Directive: d.name,
+					Source:    n.Content(),
+				})
+			}
+		}
+		return out
+	}}
+}
+
+// A cspDirective is a directive in a Content Security Policy,
+// along with any of its sources that are URLs or hosts
+type cspDirective struct {
+	name    string
+	sources []string
+}
+
+// parseCSP parses a Content Security Policy, returning false if the
+// string doesn't look like one. Every directive has to have a valid
+// name, and at least one of them has to be a directive with sources.
+func parseCSP(policy string) ([]cspDirective, bool) {
+	out := make([]cspDirective, 0)
+	hasSources := false
+
+	for _, part := range strings.Split(policy, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(fields[0])
+		if !cspDirectiveName.MatchString(name) {
+			return nil, false
+		}
+
+		if !strings.HasSuffix(name, "-src") && !cspSourceDirectives.Contains(name) {
+			continue
+		}
+		hasSources = true
+
+		d := cspDirective{name: name}
+		for _, source := range fields[1:] {
+			if cspKeyword.MatchString(source) {
+				continue
+			}
+			d.sources = append(d.sources, source)
+		}
+		out = append(out, d)
+	}
+
+	return out, hasSources
+}
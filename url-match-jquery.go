@@ -8,7 +8,7 @@ import (
 
 func matchJQuery() URLMatcher {
 
-	return URLMatcher{"call_expression", func(n *Node) *URL {
+	return URLMatcher{Name: "jQuery", Type: "call_expression", Fn: func(n *Node) *URL {
 		callName := n.ChildByFieldName("function").Content()
 
 		if !slices.Contains(
@@ -62,6 +62,7 @@ func matchJQuery() URLMatcher {
 		if firstArg.IsStringy() {
 			// first argument is the URL
 			m.URL = firstArg.CollapsedString()
+			m.RawURL = firstArg.Content()
 
 			// If the first arg is a URL, the second arg is a
 			// settings object for $.ajax, or a data object for
@@ -94,6 +95,7 @@ func matchJQuery() URLMatcher {
 
 		if m.URL == "" {
 			m.URL = settings.GetNode("url").CollapsedString()
+			m.RawURL = settings.GetNode("url").Content()
 		}
 
 		headers := settings.GetObject("headers")
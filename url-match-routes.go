@@ -0,0 +1,104 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+var routeTableName = regexp.MustCompile(`(?i)routes?|paths?|endpoints?`)
+
+func matchRouteTable() URLMatcher {
+
+	return URLMatcher{Name: "routeTable", Type: "array", MultiFn: func(n *Node) []*URL {
+		// Client-side routers are usually configured with an array of paths,
+		// or an array of objects with a path key, that is assigned to a
+		// variable with a name like 'routes'; e.g:
+		//   const routes = [{path: "/home"}, {path: "/about"}]
+		//   endpoints = ["/a", "/b", "/c"]
+		name := routeTableAssignee(n)
+		if name == "" {
+			return nil
+		}
+
+		if !routeTableName.MatchString(name) {
+			n.debugf("%s doesn't look like the name of a route table", name)
+			return nil
+		}
+
+		out := make([]*URL, 0)
+		for _, path := range routeTablePaths(n) {
+			match := &URL{
+				URL:    path.CollapsedString(),
+				RawURL: path.Content(),
+				Type:   "routeTable",
+				Source: n.Content(),
+			}
+
+			// each path is at its own position in the table
+			match.setPosition(path)
+			out = append(out, match)
+		}
+
+		return out
+	}}
+}
+
+// routeTableAssignee returns the name of the identifier that an array
+// is being assigned to, or an empty string if it isn't being assigned.
+// For member expressions (e.g. this.routes) the property name is used.
+func routeTableAssignee(n *Node) string {
+	parent := n.Parent()
+
+	var target *Node
+	switch parent.Type() {
+	case "variable_declarator":
+		target = parent.ChildByFieldName("name")
+	case "assignment_expression":
+		target = parent.ChildByFieldName("left")
+	default:
+		return ""
+	}
+
+	switch target.Type() {
+	case "identifier":
+		return target.Content()
+	case "member_expression":
+		return target.ChildByFieldName("property").Content()
+	default:
+		return ""
+	}
+}
+
+// routeTablePaths returns the nodes for the paths in a route table,
+// which are either strings in the array, or the values of 'path'
+// keys in objects in the array. Nested routes (e.g. in a 'children'
+// array, as used by Vue Router and Angular) are included too. Names
+// like imagePaths are often used for arrays of plain filenames, so
+// strings are only included if they look like paths or URLs.
+func routeTablePaths(n *Node) []*Node {
+	out := make([]*Node, 0)
+	if !n.IsValid() || n.Type() != "array" {
+		return out
+	}
+
+	for _, entry := range n.NamedChildren() {
+		switch entry.Type() {
+		case "string", "template_string":
+			path := entry.CollapsedString()
+			if strings.HasPrefix(path, "/") || MaybeURL(path) {
+				out = append(out, entry)
+			}
+		case "object":
+			o := entry.AsObject()
+
+			path := o.GetNode("path")
+			if path.IsValid() && path.IsStringy() {
+				out = append(out, path)
+			}
+
+			out = append(out, routeTablePaths(o.GetNode("children"))...)
+		}
+	}
+
+	return out
+}
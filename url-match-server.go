@@ -0,0 +1,113 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// serverRouteObject matches the names of the objects that server-side
+// routes are usually defined on; e.g. app, router, apiRouter, fastify.
+// Client-side wrappers are often called api, so that isn't included.
+var serverRouteObject = regexp.MustCompile(`(?i)^(app|server|fastify)$|router$`)
+
+// serverRouteHandlers are the types of node that can be a route's
+// handler; e.g. a function, the name of one, or a call that wraps one
+var serverRouteHandlers = map[string]bool{
+	"function":          true,
+	"arrow_function":    true,
+	"identifier":        true,
+	"member_expression": true,
+	"call_expression":   true,
+}
+
+// serverRouteVerbs maps the route definition methods to HTTP methods. An
+// empty method means the route handles any method (e.g. app.all).
+var serverRouteVerbs = map[string]string{
+	"get":     "GET",
+	"post":    "POST",
+	"put":     "PUT",
+	"patch":   "PATCH",
+	"delete":  "DELETE",
+	"del":     "DELETE",
+	"head":    "HEAD",
+	"options": "OPTIONS",
+	"all":     "",
+}
+
+func matchServerRoutes() URLMatcher {
+
+	return URLMatcher{Name: "serverRoute", Type: "call_expression", Fn: func(n *Node) *URL {
+		// Server code that ends up bundled (e.g. for SSR) defines routes with
+		// express, fastify, koa-router and the like:
+		//   app.get("/users/:id", handler)
+		//   router.post("/login", auth, handler)
+		//   fastify.route({method: "PUT", url: "/users/:id", handler})
+		fn := n.ChildByFieldName("function")
+		if fn.Type() != "member_expression" {
+			return nil
+		}
+
+		object := fn.ChildByFieldName("object")
+		if object.Type() == "member_expression" {
+			// e.g. this.app.get(...)
+			object = object.ChildByFieldName("property")
+		}
+		if !serverRouteObject.MatchString(object.Content()) {
+			return nil
+		}
+
+		arguments := n.ChildByFieldName("arguments")
+		first := arguments.NamedChild(0)
+
+		verb := fn.ChildByFieldName("property").Content()
+		if verb == "route" && first.Type() == "object" {
+			return serverRouteOptions(n, first.AsObject())
+		}
+
+		method, ok := serverRouteVerbs[verb]
+		if !ok {
+			return nil
+		}
+
+		// Express uses app.get(name) to read settings, so there has to be
+		// a handler as well as a path. Client-side calls that look like
+		// routes usually have an options object in that position instead.
+		count := arguments.NamedChildCount()
+		if count < 2 || !serverRouteHandlers[arguments.NamedChild(count-1).Type()] {
+			n.debugf("route has no handler")
+			return nil
+		}
+
+		if !first.IsStringy() || !strings.HasPrefix(first.RawString(), "/") {
+			n.debugf("first argument is not a path")
+			return nil
+		}
+
+		return &URL{
+			URL:    first.CollapsedString(),
+			RawURL: first.Content(),
+			Method: method,
+			Type:   "serverRoute",
+			Source: n.Content(),
+		}
+	}}
+}
+
+// serverRouteOptions returns a URL for the options object passed to
+// fastify.route(), which has the path in its url key. The method can
+// be an array of methods, in which case it's left empty.
+func serverRouteOptions(n *Node, o Object) *URL {
+	path := o.GetNode("url")
+	if !path.IsValid() || !path.IsStringy() || !strings.HasPrefix(path.RawString(), "/") {
+		n.debugf("route options have no url")
+		return nil
+	}
+
+	return &URL{
+		URL:    path.CollapsedString(),
+		RawURL: path.Content(),
+		Method: strings.ToUpper(o.GetString("method", "")),
+		Type:   "serverRoute",
+		Source: n.Content(),
+	}
+}
@@ -0,0 +1,267 @@
+package jsluice
+
+import (
+	"strings"
+)
+
+func matchWebpackChunks() URLMatcher {
+
+	return URLMatcher{Name: "webpackChunk", Type: "binary_expression", MultiFn: func(n *Node) []*URL {
+		// Webpack runtimes build the URLs for lazily-loaded chunks by looking
+		// up the chunk ID in maps of chunk names and hashes. In webpack 4 that's
+		// done in the jsonpScriptSrc function, and in webpack 5 it's done in
+		// __webpack_require__.u. Minified, they tend to look something like:
+		//
+		//   o.p + "static/js/" + ({0: "main"}[e] || e) + "." + {0: "a1b2", 1: "c3d4"}[e] + ".chunk.js"
+		//
+		// We don't care what the function is called, so we look for any string
+		// concatenation that uses at least one of those maps, and evaluate it
+		// for every chunk ID found in the maps.
+
+		// Only the outermost expression in a concatenation is of interest
+		if n.ChildByFieldName("operator").Content() != "+" {
+			return nil
+		}
+
+		parent := n.Parent()
+		for parent.Type() == "parenthesized_expression" {
+			parent = parent.Parent()
+		}
+		if parent.Type() == "binary_expression" {
+			return nil
+		}
+
+		maps, param := webpackChunkMaps(n)
+		if len(maps) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0)
+		seen := make(map[string]bool)
+		for _, m := range maps {
+			for _, pair := range m.NamedChildren() {
+				if pair.Type() != "pair" {
+					continue
+				}
+
+				id := pair.ChildByFieldName("key").RawString()
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		// Chunk paths are relative to the public path, which is set with an
+		// assignment like __webpack_require__.p = "/static/js/". In webpack 4
+		// the public path is part of the expression, but in webpack 5 it's
+		// added to the result of __webpack_require__.u by the chunk loader.
+		publicPath := ""
+		prefix := ""
+		if name := webpackPublicPathRef(n); name != "" {
+			publicPath, _ = findPublicPath(n, name)
+		} else if obj := webpackChunkFnObject(n); obj != "" {
+			prefix, _ = findPublicPath(n, obj+".p")
+		}
+
+		out := make([]*URL, 0, len(ids))
+		for _, id := range ids {
+			path, ok := evalChunkExpr(n, param, id, publicPath)
+			if !ok {
+				n.debugf("couldn't work out the path for chunk %s", id)
+				return nil
+			}
+			path = prefix + path
+
+			out = append(out, &URL{
+				URL:    path,
+				RawURL: n.Content(),
+				Method: "GET",
+				Type:   "webpackChunk",
+				Source: n.Content(),
+			})
+		}
+
+		return out
+	}}
+}
+
+// webpackChunkMaps returns the object literals in an expression that
+// are indexed by an identifier (e.g. {0: "main"}[e]), along with the
+// name of the identifier. All of the maps must use the same identifier.
+func webpackChunkMaps(n *Node) ([]*Node, string) {
+	maps := make([]*Node, 0)
+	param := ""
+
+	// It's not worth running a query for the vast majority
+	// of concatenations that can't possibly contain a map
+	c := n.Content()
+	if !strings.Contains(c, "}") || !strings.Contains(c, "[") {
+		return maps, param
+	}
+
+	q := `(subscript_expression
+		object: [(object) (parenthesized_expression (object))] @map
+		index: (identifier) @index
+	)`
+
+	mixed := false
+	n.QueryMulti(q, func(qr QueryResult) {
+		index := qr.Get("index").Content()
+		if param != "" && param != index {
+			mixed = true
+		}
+		param = index
+
+		m := qr.Get("map")
+		if m.Type() == "parenthesized_expression" {
+			m = m.NamedChild(0)
+		}
+		maps = append(maps, m)
+	})
+
+	if mixed {
+		return []*Node{}, ""
+	}
+
+	return maps, param
+}
+
+// webpackPublicPathRef returns the expression used to refer to the public
+// path in a chunk URL expression (e.g. __webpack_require__.p, or o.p when
+// minified), or an empty string if there isn't one
+func webpackPublicPathRef(n *Node) string {
+	ref := n.QueryOne(`
+		(member_expression
+			property: (property_identifier) @prop
+			(#eq? @prop "p")
+		) @ref
+	`)
+	if !ref.IsValid() {
+		return ""
+	}
+	return ref.Content()
+}
+
+// webpackChunkFnObject returns the name of the object that a webpack 5
+// chunk URL function is assigned to; e.g. __webpack_require__ for
+// __webpack_require__.u = (chunkId) => ..., or an empty string if the
+// expression is not part of such a function
+func webpackChunkFnObject(n *Node) string {
+	fn := n.functionScope()
+	switch fn.Type() {
+	case "function", "arrow_function":
+	default:
+		return ""
+	}
+
+	assignment := fn.Parent()
+	if assignment.Type() != "assignment_expression" {
+		return ""
+	}
+
+	left := assignment.ChildByFieldName("left")
+	if left.Type() != "member_expression" || left.ChildByFieldName("property").Content() != "u" {
+		return ""
+	}
+
+	return left.ChildByFieldName("object").Content()
+}
+
+// findPublicPath looks for a string being assigned to the public path
+// (e.g. __webpack_require__.p = "/static/js/"). The scopes that the
+// Node is in are searched from the innermost outwards, because minified
+// names like o.p can refer to different things in different places.
+func findPublicPath(n *Node, name string) (string, bool) {
+	q := `(assignment_expression
+		left: (member_expression) @left
+		right: (string) @right
+	)`
+
+	for scope := n.functionScope(); ; scope = scope.functionScope() {
+		value, found := "", false
+		scope.QueryMulti(q, func(qr QueryResult) {
+			if found || qr.Get("left").Content() != name {
+				return
+			}
+			value, found = qr.Get("right").RawString(), true
+		})
+
+		if found {
+			return value, true
+		}
+
+		if !scope.Parent().IsValid() {
+			return "", false
+		}
+	}
+}
+
+// evalChunkExpr evaluates a chunk URL expression for a single chunk ID.
+// The public path (e.g. __webpack_require__.p) is evaluated as the
+// provided value, which is an empty string if it isn't known.
+func evalChunkExpr(n *Node, param, id, publicPath string) (string, bool) {
+	switch n.Type() {
+	case "string":
+		return n.RawString(), true
+
+	case "identifier":
+		if n.Content() == param {
+			return id, true
+		}
+		return "", false
+
+	case "parenthesized_expression":
+		return evalChunkExpr(n.NamedChild(0), param, id, publicPath)
+
+	case "member_expression":
+		// the public path; e.g. __webpack_require__.p
+		if n.ChildByFieldName("property").Content() == "p" {
+			return publicPath, true
+		}
+		return "", false
+
+	case "subscript_expression":
+		m := n.ChildByFieldName("object")
+		if m.Type() == "parenthesized_expression" {
+			m = m.NamedChild(0)
+		}
+		if m.Type() != "object" || n.ChildByFieldName("index").Content() != param {
+			return "", false
+		}
+
+		// chunks without a name aren't in the map of chunk names,
+		// so there's usually a fallback; e.g. ({...}[e] || e)
+		v := m.AsObject().GetNode(id)
+		if !v.IsValid() || v.Type() != "string" {
+			return "", false
+		}
+		return v.RawString(), true
+
+	case "binary_expression":
+		left := n.ChildByFieldName("left")
+		right := n.ChildByFieldName("right")
+
+		switch n.ChildByFieldName("operator").Content() {
+		case "+":
+			l, ok := evalChunkExpr(left, param, id, publicPath)
+			if !ok {
+				return "", false
+			}
+			r, ok := evalChunkExpr(right, param, id, publicPath)
+			if !ok {
+				return "", false
+			}
+			return l + r, true
+
+		case "||":
+			if l, ok := evalChunkExpr(left, param, id, publicPath); ok {
+				return l, true
+			}
+			return evalChunkExpr(right, param, id, publicPath)
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,93 @@
+package jsluice
+
+func matchWebSockets() URLMatcher {
+
+	return URLMatcher{Name: "websocket", Type: "new_expression", Fn: func(n *Node) *URL {
+		// Realtime endpoints are opened with WebSocket, or with SockJS,
+		// which has the same API but falls back to other transports:
+		//   new WebSocket("wss://example.com/ws", ["v2.chat", "v1.chat"])
+		//   new SockJS("/sockjs", null, {transports: ["websocket"]})
+		var typ string
+		switch n.ChildByFieldName("constructor").Content() {
+		case "WebSocket", "window.WebSocket":
+			typ = "websocket"
+		case "SockJS", "window.SockJS":
+			typ = "sockjs"
+		default:
+			return nil
+		}
+
+		arguments := n.ChildByFieldName("arguments")
+		arg := arguments.NamedChild(0)
+		if !arg.IsStringy() {
+			n.debugf("first argument is not a string")
+			return nil
+		}
+
+		match := &URL{
+			URL:    arg.CollapsedString(),
+			RawURL: arg.Content(),
+			Type:   typ,
+			Source: n.Content(),
+		}
+
+		if typ == "websocket" {
+			match.Subprotocols = subprotocols(arguments.NamedChild(1))
+		}
+
+		return match
+	}}
+}
+
+func matchSocketIO() URLMatcher {
+
+	return URLMatcher{Name: "socket.io", Type: "call_expression", Fn: func(n *Node) *URL {
+		// socket.io clients connect to a namespace, or to a URL:
+		//   io("/chat")
+		//   io.connect("https://example.com/admin", {path: "/realtime"})
+		switch n.ChildByFieldName("function").Content() {
+		case "io", "io.connect":
+		default:
+			return nil
+		}
+
+		arg := n.ChildByFieldName("arguments").NamedChild(0)
+		if !arg.IsStringy() {
+			n.debugf("first argument is not a string")
+			return nil
+		}
+
+		return &URL{
+			URL:    arg.CollapsedString(),
+			RawURL: arg.Content(),
+			Type:   "socket.io",
+			Source: n.Content(),
+		}
+	}}
+}
+
+// subprotocols returns the subprotocols passed to the WebSocket
+// constructor, which are either a single string or an array of them
+func subprotocols(n *Node) []string {
+	if !n.IsValid() {
+		return nil
+	}
+
+	switch n.Type() {
+	case "string":
+		return []string{n.RawString()}
+	case "array":
+		out := make([]string, 0)
+		for _, p := range n.NamedChildren() {
+			if p.Type() == "string" {
+				out = append(out, p.RawString())
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	default:
+		return nil
+	}
+}
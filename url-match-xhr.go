@@ -1,40 +1,86 @@
 package jsluice
 
 import (
+	"container/list"
 	"strings"
 	"sync"
 
+	sitter "github.com/smacker/go-tree-sitter"
 	"golang.org/x/exp/slices"
 )
 
+// xhrCacheSize is the maximum number of scopes that matchXHR
+// keeps the sibling call_expressions for
+const xhrCacheSize = 256
+
+// nodeCache is a size-bounded LRU cache of Nodes. It's keyed on the
+// underlying tree-sitter node because methods like Parent() return a
+// new *Node each time, but the tree-sitter nodes are cached by the tree.
 type nodeCache struct {
-	sync.RWMutex
-	data map[*Node][]*Node
+	sync.Mutex
+	size  int
+	order *list.List
+	data  map[*sitter.Node]*list.Element
+}
+
+type nodeCacheEntry struct {
+	key   *sitter.Node
+	value []*Node
 }
 
-func newNodeCache() *nodeCache {
+func newNodeCache(size int) *nodeCache {
 	return &nodeCache{
-		data: make(map[*Node][]*Node),
+		size:  size,
+		order: list.New(),
+		data:  make(map[*sitter.Node]*list.Element),
 	}
 }
 
 func (c *nodeCache) set(k *Node, v []*Node) {
 	c.Lock()
-	c.data[k] = v
-	c.Unlock()
+	defer c.Unlock()
+
+	if e, exists := c.data[k.node]; exists {
+		e.Value.(*nodeCacheEntry).value = v
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.data[k.node] = c.order.PushFront(&nodeCacheEntry{key: k.node, value: v})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.data, oldest.Value.(*nodeCacheEntry).key)
+	}
 }
 
 func (c *nodeCache) get(k *Node) ([]*Node, bool) {
-	c.RLock()
-	v, exists := c.data[k]
-	c.RUnlock()
-	return v, exists
+	c.Lock()
+	defer c.Unlock()
+
+	e, exists := c.data[k.node]
+	if !exists {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*nodeCacheEntry).value, true
 }
 
+func (c *nodeCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.order.Len()
+}
+
+// matchXHR returns a new XMLHttpRequest matcher. Each matcher has its own
+// cache, and AllURLMatchers creates new matchers each time it's called, so
+// the cache isn't shared between Analyzers (except by Formatted, which
+// copies the matchers of the Analyzer it was created from).
 func matchXHR() URLMatcher {
-	cache := newNodeCache()
+	cache := newNodeCache(xhrCacheSize)
 
-	return URLMatcher{"call_expression", func(n *Node) *URL {
+	return URLMatcher{Name: "XMLHttpRequest", Type: "call_expression", Fn: func(n *Node) *URL {
 		callName := n.ChildByFieldName("function").Content()
 
 		// We don't know what the XMLHttpRequest object will be called,
@@ -55,16 +101,19 @@ func matchXHR() URLMatcher {
 			[]string{"GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH", "DELETE"},
 			method,
 		) {
+			n.debugf("first argument %q is not an HTTP method", method)
 			return nil
 		}
 
 		urlArg := arguments.NamedChild(1)
 		if !urlArg.IsStringy() {
+			n.debugf("second argument is not a string")
 			return nil
 		}
 
 		match := &URL{
 			URL:    urlArg.CollapsedString(),
+			RawURL: urlArg.Content(),
 			Method: method,
 			Type:   "XMLHttpRequest.open",
 			Source: n.Content(),
@@ -77,34 +126,18 @@ func matchXHR() URLMatcher {
 		objectName := strings.TrimSuffix(callName, ".open")
 
 		// We want to find the parent/ancestor node that defines the scope in which
-		// we are calling XHR.open(). JavaScript has three types of scope: global,
-		// function, and block. Block scope only comes into play if values
-		// are defined using 'let', or 'const'. We don't know if the XHR object
-		// was defined with let or const, so we're just going to ignore block scope.
-		// That leaves us with global scope and function scope. To find those we
-		// can ascend the tree until we hit a node with type "function_declaration",
-		// or we hit a nil parent.
-		parent := n.Parent()
-		if !parent.IsValid() {
+		// we are calling XHR.open(). We don't know if the XHR object was defined
+		// with let or const, so block scope is ignored.
+		if !n.Parent().IsValid() {
 			return match
 		}
-		for {
-			candidate := parent.Parent()
-			if candidate == nil {
-				break
-			}
-			parent = candidate
-			pt := parent.Type()
-			if pt == "function_declaration" ||
-				pt == "function" ||
-				pt == "arrow_function" {
-				break
-			}
-		}
+		parent := n.functionScope()
 
 		// Look for call_expressions under the same parent as our .open call.
-		// It's common to end up querying the exact same parent over and over
-		// again, so we cache the results on a per-parent node basis.
+		// The query matches descendants at any depth, so calls inside if, try,
+		// and other statement blocks in the same function are included. It's
+		// common to end up querying the exact same parent over and over again,
+		// so we cache the results on a per-parent node basis.
 		nodes := make([]*Node, 0)
 		if v, exists := cache.get(parent); exists {
 			nodes = v
@@ -115,7 +148,7 @@ func matchXHR() URLMatcher {
 						object: (identifier)
 						property: (property_identifier)
 					)
-					arguments: (arguments (string))
+					arguments: (arguments)
 				) @matches
 			`
 			parent.Query(q, func(sibling *Node) {
@@ -125,6 +158,7 @@ func matchXHR() URLMatcher {
 		}
 
 		headers := make(map[string]string, 0)
+		sent := false
 		// TODO: I think we can get more accuracy here by relying on the fact that
 		// the .setRequestHeader calls we're interested in must come *after* the .open
 		// call in order to be valid. In theory that means we can skip any nodes at
@@ -134,6 +168,17 @@ func matchXHR() URLMatcher {
 		// cause us to miss some values.
 		for _, sibling := range nodes {
 			name := sibling.ChildByFieldName("function").Content()
+
+			// The body is sent with .send(body), which has to come after the
+			// .open call. Only the first .send is used, because it's likely
+			// that any others belong to a different request.
+			if name == objectName+".send" && !sent &&
+				sibling.node.StartByte() > n.node.StartByte() {
+				match.BodyParams = bodyParams(sibling.ChildByFieldName("arguments").NamedChild(0))
+				sent = true
+				continue
+			}
+
 			if !strings.HasSuffix(name, ".setRequestHeader") {
 				continue
 			}
@@ -1,6 +1,8 @@
 package jsluice
 
 import (
+	"html"
+	"net"
 	"net/url"
 	"regexp"
 	"strings"
@@ -15,6 +17,53 @@ type URL struct {
 	Headers     map[string]string `json:"headers,omitempty"`
 	ContentType string            `json:"contentType,omitempty"`
 
+	// whether credentials (e.g. cookies) are sent with the request; one of
+	// include, omit, or same-origin, as set with fetch's credentials option
+	// or axios's withCredentials option. Requests that include credentials
+	// for another origin are worth a closer look.
+	Credentials string `json:"credentials,omitempty"`
+
+	// fetch's request mode; e.g. cors, no-cors, or same-origin
+	Mode string `json:"mode,omitempty"`
+
+	// one of absolute (https://example.com/), scheme-relative (//example.com/),
+	// root-relative (/path), or relative (path or ../path)
+	Class string `json:"class"`
+
+	// true if the URL's host is a private, loopback, or link-local IP
+	// address, or a hostname that isn't publicly resolvable (e.g. localhost,
+	// or something ending in .internal or .corp)
+	Internal bool `json:"internal,omitempty"`
+
+	// the client_id, redirect_uri etc for OAuth authorization URLs; e.g.
+	// https://accounts.google.com/o/oauth2/auth?client_id=...
+	OAuth *OAuthParams `json:"oauth,omitempty"`
+
+	// the service, project, and database for Firebase Realtime Database
+	// and Firestore URLs; e.g. https://my-app.firebaseio.com
+	Firebase *FirebaseDatabase `json:"firebase,omitempty"`
+
+	// the name of the bucket for S3 URLs; e.g. assets for
+	// https://assets.s3.amazonaws.com/ or https://s3.amazonaws.com/assets/
+	S3Bucket string `json:"s3Bucket,omitempty"`
+
+	// the names of parameters in the path; e.g. id for /users/:id,
+	// /users/{id}, or /users/${id}. Expressions that don't have a
+	// simple name are included as the placeholder.
+	PathParams []string `json:"pathParams,omitempty"`
+
+	// the Content Security Policy directive that a cspSource URL was
+	// listed in; e.g. script-src or connect-src
+	Directive string `json:"directive,omitempty"`
+
+	// the subprotocols requested when opening a WebSocket; e.g.
+	// new WebSocket(url, ["v2.chat", "v1.chat"])
+	Subprotocols []string `json:"subprotocols,omitempty"`
+
+	// the original source of the string or expression that URL was collapsed
+	// from, so that values replaced by the placeholder can be inferred
+	RawURL string `json:"rawUrl,omitempty"`
+
 	// some description like locationAssignment, fetch, $.post or something like that
 	Type string `json:"type"`
 
@@ -23,87 +72,458 @@ type URL struct {
 
 	// the filename in which the match was found
 	Filename string `json:"filename,omitempty"`
+
+	// where the match was found in the source that was analyzed; nil for
+	// URLs that don't come from the parse tree, like those found in CSS
+	Position *Position `json:"position,omitempty"`
+}
+
+// Less reports whether the URL should sort before the other URL.
+// URLs are ordered by filename, then by where they were found in the
+// file, then by URL, and then by type.
+func (u *URL) Less(other *URL) bool {
+	if u.Filename != other.Filename {
+		return u.Filename < other.Filename
+	}
+	if c := comparePositions(u.Position, other.Position); c != 0 {
+		return c < 0
+	}
+	if u.URL != other.URL {
+		return u.URL < other.URL
+	}
+	return u.Type < other.Type
+}
+
+// setPosition sets the position of a URL to the position of the node it
+// was found in, unless the matcher that found it has already set one
+func (u *URL) setPosition(n *Node) {
+	if u.Position != nil {
+		return
+	}
+	position := n.Position()
+	u.Position = &position
 }
 
 // GetURLs searches the JavaScript source code for absolute and relative URLs and returns
-// a slice of results.
+// a slice of results. If MaxResults is set, no more than that many results are returned,
+// and Truncated reports whether any were dropped.
 func (a *Analyzer) GetURLs() []*URL {
 
 	matches := make([]*URL, 0)
-
-	re := regexp.MustCompile("[^A-Z-a-z]")
+	a.truncated = false
 
 	// function to run on entry to each node in the tree
 	enter := func(n *Node) {
+		if a.truncated {
+			return
+		}
 
 		for _, matcher := range a.urlMatchers {
 			if matcher.Type != n.Type() {
 				continue
 			}
 
-			match := matcher.Fn(n)
-			if match == nil {
+			found := matcher.matches(n)
+			if len(found) == 0 {
+				a.logDecision(matcher.Name, n, nil, false)
 				continue
 			}
 
-			// decode any escapes in the URL
-			match.URL = DecodeString(match.URL)
+			for _, match := range found {
+				// decode any escapes in the URL
+				match.URL = DecodeString(match.URL)
 
-			// an empty slice is easier to deal with than null, e.g when using jq
-			if match.QueryParams == nil {
-				match.QueryParams = []string{}
-			}
-			if match.BodyParams == nil {
-				match.BodyParams = []string{}
-			}
+				match.setPosition(n)
 
-			// Filter out data: and tel: schemes etc
-			lower := strings.ToLower(match.URL)
-			if strings.HasPrefix(lower, "data:") ||
-				strings.HasPrefix(lower, "tel:") ||
-				strings.HasPrefix(lower, "about:") ||
-				strings.HasPrefix(lower, "javascript:") {
-				continue
+				kept := a.cleanURL(match)
+				a.logDecision(matcher.Name, n, match, kept)
+				if !kept {
+					continue
+				}
+
+				if a.atLimit(len(matches)) {
+					return
+				}
+				matches = append(matches, match)
 			}
+		}
+	}
 
-			// Look for URLs that are entirely made up of EXPR replacements
-			// and skip them. Maybe this should be optional? Maybe it should
-			// remove things like EXPR#EXPR etc too
-			letters := re.ReplaceAllString(match.URL, "")
-			if strings.ReplaceAll(letters, ExpressionPlaceholder, "") == "" {
-				continue
+	// find the nodes we need in the the tree and run the enter function for every node
+	a.Query("[(assignment_expression) (call_expression) (new_expression) (string) (array) (binary_expression)] @matches", enter)
+
+	// comments are only searched if the option is set, because they tend
+	// to contain a lot of links to documentation, licenses and so on
+	if a.IncludeComments {
+		a.Query("(comment) @matches", func(n *Node) {
+			if a.truncated {
+				return
 			}
 
-			// Parse any query params out of the URL and add them. Some, but not
-			// all of the matchers will add query params, so we want to do it here
-			// and then remove duplicates
-			u, err := url.Parse(match.URL)
-			if err == nil {
-				// manually disallow www.w3.org just because it shows up so damn often
-				if u.Hostname() == "www.w3.org" {
+			for _, match := range commentURLs(n) {
+				match.setPosition(n)
+
+				kept := a.cleanURL(match)
+				a.logDecision("comment", n, match, kept)
+				if !kept {
 					continue
 				}
 
-				for p, _ := range u.Query() {
-					// Ignore params that were expressions
-					if p == ExpressionPlaceholder {
-						continue
-					}
-					match.QueryParams = append(match.QueryParams, p)
+				if a.atLimit(len(matches)) {
+					return
 				}
+				matches = append(matches, match)
 			}
-			match.QueryParams = unique(match.QueryParams)
+		})
+	}
 
-			matches = append(matches, match)
-		}
+	// any CSS that was found alongside the JavaScript (e.g. in <style> tags)
+	// can contain URLs too, but it doesn't have a parse tree to match against
+	if !a.IncludeStyles {
+		return matches
 	}
 
-	// find the nodes we need in the the tree and run the enter function for every node
-	a.Query("[(assignment_expression) (call_expression) (string)] @matches", enter)
+	for _, match := range cssURLs(a.css) {
+		kept := a.cleanURL(match)
+		a.logDecision("css", nil, match, kept)
+		if !kept {
+			continue
+		}
+
+		if a.atLimit(len(matches)) {
+			break
+		}
+		matches = append(matches, match)
+	}
 
 	return matches
 }
 
+var nonLetters = regexp.MustCompile("[^A-Z-a-z]")
+
+// cleanURL fills in any missing fields for a match, and adds any query
+// params found in the URL. It returns false if the match should be discarded.
+func (a *Analyzer) cleanURL(match *URL) bool {
+	// an empty slice is easier to deal with than null, e.g when using jq
+	if match.QueryParams == nil {
+		match.QueryParams = []string{}
+	}
+	if match.BodyParams == nil {
+		match.BodyParams = []string{}
+	}
+
+	// e.g. /api?a=1&amp;b=2 in an inline script
+	if a.DecodeHTMLEntities {
+		match.URL = html.UnescapeString(match.URL)
+	}
+
+	// Filter out data: and tel: schemes etc
+	lower := strings.ToLower(match.URL)
+	if strings.HasPrefix(lower, "data:") ||
+		strings.HasPrefix(lower, "tel:") ||
+		strings.HasPrefix(lower, "about:") ||
+		strings.HasPrefix(lower, "javascript:") {
+		a.debugf("unwanted scheme")
+		return false
+	}
+
+	// Look for URLs that are entirely made up of EXPR replacements
+	// and skip them. Maybe this should be optional? Maybe it should
+	// remove things like EXPR#EXPR etc too
+	letters := nonLetters.ReplaceAllString(match.URL, "")
+	if strings.ReplaceAll(letters, a.ExpressionPlaceholder, "") == "" {
+		a.debugf("only contains expressions")
+		return false
+	}
+
+	// Parse any query params out of the URL and add them. Some, but not
+	// all of the matchers will add query params, so we want to do it here
+	// and then remove duplicates
+	u, err := url.Parse(match.URL)
+	if err == nil {
+		// manually disallow www.w3.org just because it shows up so damn often
+		if u.Hostname() == "www.w3.org" {
+			a.debugf("www.w3.org is always ignored")
+			return false
+		}
+
+		for p, _ := range u.Query() {
+			// Ignore params that were expressions
+			if p == a.ExpressionPlaceholder {
+				continue
+			}
+			match.QueryParams = append(match.QueryParams, p)
+		}
+
+		match.OAuth = oauthParams(u)
+		match.Firebase = firebaseDatabase(u)
+		match.S3Bucket = s3Bucket(u)
+	}
+	match.QueryParams = unique(match.QueryParams)
+
+	match.PathParams = pathParams(match, a.ExpressionPlaceholder)
+	match.Class = urlClass(match.URL)
+	match.Internal = isInternalURL(match.URL, match.Class, a.ExpressionPlaceholder)
+
+	return true
+}
+
+var urlScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// urlClass returns the class of a URL; i.e. whether it's absolute,
+// scheme-relative, root-relative, or relative to the current path
+func urlClass(u string) string {
+	switch {
+	case strings.HasPrefix(u, "//"):
+		return "scheme-relative"
+	case strings.HasPrefix(u, "/"):
+		return "root-relative"
+	case urlScheme.MatchString(u):
+		return "absolute"
+	default:
+		return "relative"
+	}
+}
+
+// internalSuffixes are the suffixes of hostnames that
+// are only used on internal networks
+var internalSuffixes = []string{
+	".localhost",
+	".localdomain",
+	".internal",
+	".intranet",
+	".corp",
+	".local",
+	".lan",
+	".home",
+	".home.arpa",
+	".private",
+}
+
+var hostPort = regexp.MustCompile(`^[a-zA-Z0-9.-]+:[0-9]+(/|$)`)
+
+// isInternalURL returns true if the host for a URL is internal; see
+// isInternalHost. Relative URLs don't have a host, but hosts without a
+// scheme are common enough (e.g. api.internal/v1) that they're
+// checked too, albeit only for the more obvious internal hosts.
+// Hosts that were built from expressions (i.e. that contain the
+// placeholder) could be anything, so they're never internal.
+func isInternalURL(u, class, placeholder string) bool {
+	switch class {
+	case "absolute", "scheme-relative":
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return false
+		}
+
+		// e.g. localhost:8080/api looks like it has a scheme of localhost
+		if hostPort.MatchString(u) {
+			parsed, err = url.Parse("//" + u)
+			if err != nil {
+				return false
+			}
+		}
+
+		host := parsed.Hostname()
+		if placeholder != "" && strings.Contains(host, placeholder) {
+			return false
+		}
+
+		// single-label hostnames like http://jenkins/ only
+		// resolve on the network they were written for
+		if host != "" && !strings.Contains(host, ".") && net.ParseIP(host) == nil {
+			return true
+		}
+
+		return isInternalHost(host)
+
+	case "relative":
+		parsed, err := url.Parse("//" + u)
+		if err != nil {
+			return false
+		}
+
+		host := parsed.Hostname()
+		if placeholder != "" && strings.Contains(host, placeholder) {
+			return false
+		}
+		return isInternalHost(host)
+
+	default:
+		return false
+	}
+}
+
+// isInternalHost returns true if the host is a private, loopback, or
+// link-local IP address, localhost, or has an internal-only suffix
+func isInternalHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsPrivate() ||
+			ip.IsLoopback() ||
+			ip.IsLinkLocalUnicast() ||
+			ip.IsUnspecified()
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" {
+		return true
+	}
+
+	for _, suffix := range internalSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// axiosMethods maps axios's request method aliases to HTTP methods
+var axiosMethods = map[string]string{
+	"axios.get":     "GET",
+	"axios.delete":  "DELETE",
+	"axios.head":    "HEAD",
+	"axios.options": "OPTIONS",
+	"axios.post":    "POST",
+	"axios.put":     "PUT",
+	"axios.patch":   "PATCH",
+}
+
+// axiosCredentials returns whether an axios request sends credentials,
+// using the same values as fetch's credentials option. Setting
+// withCredentials to false still sends credentials to the same origin.
+func axiosCredentials(config *Node) string {
+	if !config.IsValid() || config.Type() != "object" {
+		return ""
+	}
+
+	value := config.AsObject().GetNode("withCredentials")
+	if !value.IsValid() {
+		return ""
+	}
+
+	switch value.Type() {
+	case "true":
+		return "include"
+	case "false":
+		return "same-origin"
+	default:
+		return ""
+	}
+}
+
+// endsWithString returns true if a Node is a concatenation
+// whose last operand is a string; e.g. someVar + "/path"
+func endsWithString(n *Node) bool {
+	if n.Type() != "binary_expression" || n.ChildByFieldName("operator").Content() != "+" {
+		return false
+	}
+
+	parts := concatOperands(n)
+	return parts[len(parts)-1].Type() == "string"
+}
+
+// verbMethods maps the verbs that are used in the names of functions
+// that make HTTP requests to the methods they use
+var verbMethods = map[string]string{
+	"get":    "GET",
+	"post":   "POST",
+	"put":    "PUT",
+	"patch":  "PATCH",
+	"delete": "DELETE",
+	"del":    "DELETE",
+}
+
+var (
+	// acronymBoundary and camelBoundary match where the words in camelCase
+	// names start; e.g. before Post in HTTPPost, and before JSON in getJSON
+	acronymBoundary = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
+	camelBoundary   = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	nonWord         = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// nameWords splits a camelCase or snake_case name into its words
+func nameWords(name string) []string {
+	name = acronymBoundary.ReplaceAllString(name, "$1 $2")
+	name = camelBoundary.ReplaceAllString(name, "$1 $2")
+	return strings.Fields(nonWord.ReplaceAllString(name, " "))
+}
+
+// domGetters are the (lowercased) names of DOM and storage methods that
+// start with get but don't make requests, even though they're often
+// passed strings that look like paths; e.g. localStorage.getItem("/cart")
+var domGetters = newSet([]string{
+	"getattribute",
+	"getattributens",
+	"getboundingclientrect",
+	"getcomputedstyle",
+	"getcontext",
+	"getelementbyid",
+	"getelementsbyclassname",
+	"getelementsbyname",
+	"getelementsbytagname",
+	"getitem",
+	"getpropertyvalue",
+})
+
+// inferMethod returns the HTTP method for a function that's named after
+// one; e.g. GET for getJSON or api.httpGet, and DELETE for deleteUser.
+// Only the name of the function itself is used, not the object it's a
+// property of, and the verb has to be the first or last word of the name,
+// so that e.g. resetPutOptions isn't taken to be a PUT. An empty string
+// is returned if there's no verb, or for DOM and storage getters.
+func inferMethod(callName string) string {
+	if i := strings.LastIndex(callName, "."); i != -1 {
+		callName = callName[i+1:]
+	}
+
+	if domGetters.Contains(strings.ToLower(callName)) {
+		return ""
+	}
+
+	words := nameWords(callName)
+	if len(words) == 0 {
+		return ""
+	}
+
+	for _, word := range []string{words[0], words[len(words)-1]} {
+		if method, exists := verbMethods[strings.ToLower(word)]; exists {
+			return method
+		}
+	}
+
+	return ""
+}
+
+// bodyParams returns the keys of an object literal that is sent as a
+// request body, including shorthand properties (e.g. {name, email}).
+// Object literals passed to JSON.stringify are used too, but nil is
+// returned for anything else.
+func bodyParams(body *Node) []string {
+	if !body.IsValid() {
+		return nil
+	}
+
+	if body.Type() == "call_expression" && body.ChildByFieldName("function").Content() == "JSON.stringify" {
+		body = body.ChildByFieldName("arguments").NamedChild(0)
+	}
+
+	if body.Type() != "object" {
+		return nil
+	}
+
+	out := make([]string, 0)
+	for _, child := range body.NamedChildren() {
+		switch child.Type() {
+		case "pair":
+			out = append(out, child.ChildByFieldName("key").RawString())
+		case "shorthand_property_identifier":
+			out = append(out, child.Content())
+		}
+	}
+	return out
+}
+
 func unique[T comparable](items []T) []T {
 	set := make(map[T]any)
 	for _, item := range items {
@@ -118,11 +538,30 @@ func unique[T comparable](items []T) []T {
 	return out
 }
 
-// A URLMatcher has a type of thing it matches against (e.g. assignment_expression),
+// A URLMatcher has a name, a type of thing it matches against (e.g. assignment_expression),
 // and a function to actually do the matching and producing of the *URL
 type URLMatcher struct {
 	Type string
 	Fn   func(*Node) *URL
+
+	// MultiFn can be provided instead of Fn by matchers
+	// that can find more than one URL in a single node
+	MultiFn func(*Node) []*URL
+
+	Name string
+}
+
+// matches runs the URLMatcher against the provided Node
+func (m URLMatcher) matches(n *Node) []*URL {
+	if m.MultiFn != nil {
+		return m.MultiFn(n)
+	}
+
+	match := m.Fn(n)
+	if match == nil {
+		return nil
+	}
+	return []*URL{match}
 }
 
 // AddURLMatcher allows custom URLMatchers to be added to the Analyzer
@@ -170,6 +609,36 @@ func AllURLMatchers() []URLMatcher {
 		return false
 	}
 
+	// Functions that are often passed things that look like paths,
+	// but that have nothing to do with HTTP requests
+	ignoredCallNames := newSet([]string{
+		"require",
+		"describe",
+		"it",
+		"test",
+		"expect",
+		"path.join",
+		"path.resolve",
+		"path.normalize",
+		"path.relative",
+		// the URL is reported for whatever the encoded value is passed to
+		"encodeURI",
+		"encodeURIComponent",
+	})
+
+	isIgnoredCall := func(name string) bool {
+		if ignoredCallNames.Contains(name) {
+			return true
+		}
+
+		// e.g. console.log, console.error
+		if strings.HasPrefix(name, "console.") {
+			return true
+		}
+
+		return false
+	}
+
 	matchers := []URLMatcher{
 		// XMLHttpRequest.open(method, url)
 		matchXHR(),
@@ -177,8 +646,32 @@ func AllURLMatchers() []URLMatcher {
 		// $.post, $.get, and $.ajax
 		matchJQuery(),
 
+		// const routes = [{path: "/home"}, {path: "/about"}]
+		matchRouteTable(),
+
+		// app.get("/users/:id", handler)
+		matchServerRoutes(),
+
+		// new WebSocket(url, protocols), new SockJS(url)
+		matchWebSockets(),
+
+		// io("/namespace"), io.connect(url)
+		matchSocketIO(),
+
+		// "https://my-app.firebaseio.com", "https://firestore.googleapis.com/v1/projects/..."
+		matchFirebase(),
+
+		// "default-src 'self'; script-src https://cdn.example.com"
+		matchCSP(),
+
+		// o.p + "static/js/" + ({0: "main"}[e] || e) + "." + {0: "a1b2"}[e] + ".chunk.js"
+		matchWebpackChunks(),
+
+		// if (e.url === "api/user/login")
+		matchComparisons(),
+
 		// location assignment
-		{"assignment_expression", func(n *Node) *URL {
+		{Name: "locationAssignment", Type: "assignment_expression", MultiFn: func(n *Node) []*URL {
 			left := n.ChildByFieldName("left")
 			right := n.ChildByFieldName("right")
 
@@ -197,20 +690,37 @@ func AllURLMatchers() []URLMatcher {
 			//
 			// So while we might miss out on some things this way, they probably wouldn't
 			// have been super useful to anything automated anyway.
-			if !right.IsStringy() {
+			//
+			// Conditional redirects (e.g. cond ? "/a" : "/b") result in a URL for
+			// each branch that starts with a string.
+			alternatives := right.StringyAlternatives()
+
+			// The MatchTrailingStrings option keeps the second kind too, as
+			// EXPR/somePath/, for anyone who wants the known part of the path
+			if len(alternatives) == 0 && n.matchTrailingStrings() && endsWithString(right) {
+				alternatives = []*Node{right}
+			}
+
+			if len(alternatives) == 0 {
+				n.debugf("assigned value is not a string")
 				return nil
 			}
 
-			return &URL{
-				URL:    right.CollapsedString(),
-				Method: "GET",
-				Type:   "locationAssignment",
-				Source: n.Content(),
+			out := make([]*URL, 0, len(alternatives))
+			for _, alt := range alternatives {
+				out = append(out, &URL{
+					URL:    alt.CollapsedString(),
+					RawURL: alt.Content(),
+					Method: "GET",
+					Type:   "locationAssignment",
+					Source: n.Content(),
+				})
 			}
+			return out
 		}},
 
 		// location replacement
-		{"call_expression", func(n *Node) *URL {
+		{Name: "locationReplacement", Type: "call_expression", MultiFn: func(n *Node) []*URL {
 			callName := n.ChildByFieldName("function").Content()
 
 			if !strings.HasSuffix(callName, "location.replace") {
@@ -219,34 +729,52 @@ func AllURLMatchers() []URLMatcher {
 
 			arguments := n.ChildByFieldName("arguments")
 
-			// check the argument contains at least one string literal
-			if !arguments.NamedChild(0).IsStringy() {
+			// check the argument contains at least one string literal,
+			// or is a ternary with at least one branch that does
+			alternatives := arguments.NamedChild(0).StringyAlternatives()
+			if len(alternatives) == 0 {
+				n.debugf("first argument is not a string")
 				return nil
 			}
 
-			return &URL{
-				URL:    arguments.NamedChild(0).CollapsedString(),
-				Method: "GET",
-				Type:   "locationReplacement",
-				Source: n.Content(),
+			out := make([]*URL, 0, len(alternatives))
+			for _, alt := range alternatives {
+				out = append(out, &URL{
+					URL:    alt.CollapsedString(),
+					RawURL: alt.Content(),
+					Method: "GET",
+					Type:   "locationReplacement",
+					Source: n.Content(),
+				})
 			}
+			return out
 		}},
 
 		// window.open(url)
-		{"call_expression", func(n *Node) *URL {
+		{Name: "window.open", Type: "call_expression", Fn: func(n *Node) *URL {
 			callName := n.ChildByFieldName("function").Content()
 			if callName != "window.open" && callName != "open" {
 				return nil
 			}
+
+			// open is a common name for functions and methods, so a call to
+			// a bare open() is only treated as window.open() if it doesn't
+			// refer to something that's been declared
+			if callName == "open" && n.isBound("open") {
+				n.debugf("open is declared in scope")
+				return nil
+			}
 			arguments := n.ChildByFieldName("arguments")
 
 			// check the argument contains at least one string literal
 			if !arguments.NamedChild(0).IsStringy() {
+				n.debugf("first argument is not a string")
 				return nil
 			}
 
 			return &URL{
 				URL:    arguments.NamedChild(0).CollapsedString(),
+				RawURL: arguments.NamedChild(0).Content(),
 				Method: "GET",
 				Type:   "window.open",
 				Source: n.Content(),
@@ -254,8 +782,81 @@ func AllURLMatchers() []URLMatcher {
 			return nil
 		}},
 
+		// new Worker(url), new SharedWorker(url)
+		{Name: "worker", Type: "new_expression", Fn: func(n *Node) *URL {
+			switch n.ChildByFieldName("constructor").Content() {
+			case "Worker", "SharedWorker", "window.Worker", "window.SharedWorker":
+			default:
+				return nil
+			}
+
+			arg := n.ChildByFieldName("arguments").NamedChild(0)
+
+			// Bundlers like webpack and Vite need worker URLs to be
+			// written as new Worker(new URL("./worker.js", import.meta.url))
+			if arg.Type() == "new_expression" && arg.ChildByFieldName("constructor").Content() == "URL" {
+				arg = arg.ChildByFieldName("arguments").NamedChild(0)
+			}
+
+			if !arg.IsStringy() {
+				n.debugf("first argument is not a string")
+				return nil
+			}
+
+			return &URL{
+				URL:    arg.CollapsedString(),
+				RawURL: arg.Content(),
+				Method: "GET",
+				Type:   "worker",
+				Source: n.Content(),
+			}
+		}},
+
+		// new URL(url, [base])
+		{Name: "urlConstructor", Type: "new_expression", Fn: func(n *Node) *URL {
+			switch n.ChildByFieldName("constructor").Content() {
+			case "URL", "window.URL":
+			default:
+				return nil
+			}
+
+			arguments := n.ChildByFieldName("arguments")
+			arg := arguments.NamedChild(0)
+			if !arg.IsStringy() {
+				n.debugf("first argument is not a string")
+				return nil
+			}
+
+			match := &URL{
+				URL:    arg.CollapsedString(),
+				RawURL: arg.Content(),
+				Type:   "urlConstructor",
+				Source: n.Content(),
+			}
+
+			// A base that's a string literal can be resolved against straight
+			// away; others (e.g. location.origin) are left for --resolve-paths
+			base := arguments.NamedChild(1)
+			if !base.IsValid() || base.Type() != "string" {
+				return match
+			}
+
+			baseURL, err := url.Parse(base.RawString())
+			if err != nil || !baseURL.IsAbs() {
+				return match
+			}
+
+			ref, err := url.Parse(match.URL)
+			if err != nil {
+				return match
+			}
+			match.URL = baseURL.ResolveReference(ref).String()
+
+			return match
+		}},
+
 		// fetch(url, [init])
-		{"call_expression", func(n *Node) *URL {
+		{Name: "fetch", Type: "call_expression", Fn: func(n *Node) *URL {
 			callName := n.ChildByFieldName("function").Content()
 			if callName != "fetch" {
 				return nil
@@ -264,6 +865,7 @@ func AllURLMatchers() []URLMatcher {
 
 			// check the argument contains at least one string literal
 			if !arguments.NamedChild(0).IsStringy() {
+				n.debugf("first argument is not a string")
 				return nil
 			}
 
@@ -271,9 +873,13 @@ func AllURLMatchers() []URLMatcher {
 
 			return &URL{
 				URL:         arguments.NamedChild(0).CollapsedString(),
+				RawURL:      arguments.NamedChild(0).Content(),
 				Method:      init.GetString("method", "GET"),
 				Headers:     init.GetObject("headers").AsMap(),
 				ContentType: init.GetObject("headers").GetStringI("content-type", ""),
+				BodyParams:  bodyParams(init.GetNode("body")),
+				Credentials: init.GetString("credentials", ""),
+				Mode:        init.GetString("mode", ""),
 				Type:        "fetch",
 				Source:      n.Content(),
 			}
@@ -281,8 +887,12 @@ func AllURLMatchers() []URLMatcher {
 		}},
 
 		// other function calls with a URL-like argument
-		{"call_expression", func(n *Node) *URL {
+		{Name: "functionCall", Type: "call_expression", Fn: func(n *Node) *URL {
 			callName := n.ChildByFieldName("function").Content()
+			if isIgnoredCall(callName) {
+				n.debugf("%s is an ignored function", callName)
+				return nil
+			}
 
 			arguments := n.ChildByFieldName("arguments")
 			if !arguments.NamedChild(0).IsStringy() {
@@ -290,14 +900,34 @@ func AllURLMatchers() []URLMatcher {
 			}
 
 			if !MaybeURL(arguments.NamedChild(0).CollapsedString()) {
+				n.debugf("first argument failed MaybeURL")
 				return nil
 			}
 
-			return &URL{
+			match := &URL{
 				URL:    arguments.NamedChild(0).CollapsedString(),
+				RawURL: arguments.NamedChild(0).Content(),
 				Type:   callName,
 				Source: n.Content(),
 			}
+
+			// e.g. axios.post(url, data, config)
+			if method, exists := axiosMethods[callName]; exists {
+				match.Method = method
+
+				config := arguments.NamedChild(1)
+				if method == "POST" || method == "PUT" || method == "PATCH" {
+					match.BodyParams = bodyParams(arguments.NamedChild(1))
+					config = arguments.NamedChild(2)
+				}
+				match.Credentials = axiosCredentials(config)
+				return match
+			}
+
+			// e.g. api.getJSON(url), deleteUser(url)
+			match.Method = inferMethod(callName)
+
+			return match
 		}},
 
 		// string literals
@@ -305,15 +935,22 @@ func AllURLMatchers() []URLMatcher {
 		// that provides the least amount of context. When doing
 		// de-duplication based on the path that means that a
 		// duplicate with more context would "win" if one exists
-		{"string", func(n *Node) *URL {
+		{Name: "stringLiteral", Type: "string", Fn: func(n *Node) *URL {
+			if max := n.maxURLStringLength(); max > 0 && int(n.node.EndByte()-n.node.StartByte()) > max {
+				n.debugf("longer than %d bytes", max)
+				return nil
+			}
+
 			trimmed := n.RawString()
 
 			if !MaybeURL(trimmed) {
+				n.debugf("failed MaybeURL")
 				return nil
 			}
 
 			return &URL{
 				URL:    trimmed,
+				RawURL: n.Content(),
 				Type:   "stringLiteral",
 				Source: n.Content(),
 			}
@@ -0,0 +1,56 @@
+package jsluice
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// OAuthParams holds the parameters of an OAuth authorization request,
+// which are interesting when testing authentication flows; e.g. a
+// hardcoded client_id paired with a redirect_uri that can be changed.
+type OAuthParams struct {
+	ClientID     string `json:"clientId,omitempty"`
+	RedirectURI  string `json:"redirectUri,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	ResponseType string `json:"responseType,omitempty"`
+}
+
+// oauthPath matches the paths of well-known OAuth authorize endpoints; e.g.
+//
+//	accounts.google.com/o/oauth2/auth and /o/oauth2/v2/auth
+//	login.microsoftonline.com/common/oauth2/v2.0/authorize
+//	github.com/login/oauth/authorize
+//	www.facebook.com/v18.0/dialog/oauth
+//	appleid.apple.com/auth/authorize
+//	slack.com/oauth/v2/authorize
+//	discord.com/api/oauth2/authorize
+var oauthPath = regexp.MustCompile(`(?i)/oauth2?(/v[0-9.]+)?/(auth|authorize)/?$|/dialog/oauth/?$|^/auth/authorize/?$`)
+
+// oauthParams returns the OAuth parameters for a URL if it looks like an
+// OAuth authorization request, or nil if it doesn't. A URL counts if it's
+// for a well-known authorize endpoint, or if it has a client_id parameter
+// along with a redirect_uri or response_type parameter.
+func oauthParams(u *url.URL) *OAuthParams {
+	q := u.Query()
+
+	known := oauthPath.MatchString(u.Path)
+	hasParams := q.Has("client_id") && (q.Has("redirect_uri") || q.Has("response_type"))
+
+	if !known && !hasParams {
+		return nil
+	}
+
+	// The path alone isn't enough for relative URLs, because /oauth/authorize
+	// etc are also the paths of the pages that handle the redirect
+	if !hasParams && u.Host == "" {
+		return nil
+	}
+
+	return &OAuthParams{
+		ClientID:     q.Get("client_id"),
+		RedirectURI:  q.Get("redirect_uri"),
+		Scope:        strings.TrimSpace(q.Get("scope")),
+		ResponseType: q.Get("response_type"),
+	}
+}
@@ -0,0 +1,113 @@
+package jsluice
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// e.g. :id or :id? as used by express and most client-side routers
+	colonPathParam = regexp.MustCompile(`^:([A-Za-z_$][\w$]*)\??$`)
+
+	// e.g. {id} as used in OpenAPI specs and ASP.NET routes
+	bracePathParam = regexp.MustCompile(`^\{([A-Za-z_$][\w$]*)\}$`)
+
+	// the expressions in a template string; e.g. ${user.id}
+	templateExpression = regexp.MustCompile(`\$\{([^{}]*)\}`)
+
+	// quoted strings in a concatenation; e.g. "/users/" + id
+	quotedString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`)
+
+	// expressions that a parameter name can be taken from; e.g. id or user.id
+	simpleExpression = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+)
+
+// pathParams returns the names of the parameters in the path of a URL,
+// which are segments like :id or {id}, or segments that contain an
+// expression; e.g. /users/${id}. Where the expressions in the raw URL are
+// simple enough their names are used (the property name for things like
+// user.id), otherwise the placeholder is. It returns nil if there are no
+// parameters in the path.
+func pathParams(u *URL, placeholder string) []string {
+	// drop the query string and fragment, which both
+	// often contain expressions that aren't in the path
+	end := strings.IndexAny(u.URL, "?#")
+	if end == -1 {
+		end = len(u.URL)
+	}
+
+	// and the scheme and host
+	start := 0
+	if i := strings.Index(u.URL[:end], "//"); i == 0 || i > 0 && u.URL[i-1] == ':' {
+		start = end
+		if j := strings.Index(u.URL[i+2:end], "/"); j != -1 {
+			start = i + 2 + j
+		}
+	}
+	path := u.URL[start:end]
+
+	// any expressions in the host come before the ones in the path
+	names := expressionNames(u.RawURL, strings.Count(u.URL, placeholder))
+	used := strings.Count(u.URL[:start], placeholder)
+
+	var out []string
+	for _, segment := range strings.Split(path, "/") {
+		if m := colonPathParam.FindStringSubmatch(segment); m != nil {
+			out = append(out, m[1])
+			continue
+		}
+
+		if m := bracePathParam.FindStringSubmatch(segment); m != nil {
+			out = append(out, m[1])
+			continue
+		}
+
+		for i := strings.Count(segment, placeholder); i > 0; i-- {
+			if names != nil && used < len(names) {
+				out = append(out, names[used])
+			} else {
+				out = append(out, placeholder)
+			}
+			used++
+		}
+	}
+
+	return out
+}
+
+// expressionNames returns a name for each of the expressions in the
+// raw source of a URL, in order, or nil if there aren't the expected
+// number of them, which means they can't be matched up with the
+// placeholders in the collapsed URL
+func expressionNames(raw string, expected int) []string {
+	if expected == 0 {
+		return nil
+	}
+
+	var exprs []string
+	if strings.HasPrefix(raw, "`") {
+		for _, m := range templateExpression.FindAllStringSubmatch(raw, -1) {
+			exprs = append(exprs, m[1])
+		}
+	} else {
+		for _, part := range strings.Split(quotedString.ReplaceAllString(raw, ""), "+") {
+			if part = strings.TrimSpace(part); part != "" {
+				exprs = append(exprs, part)
+			}
+		}
+	}
+
+	if len(exprs) != expected {
+		return nil
+	}
+
+	names := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		expr = strings.TrimSpace(expr)
+		if !simpleExpression.MatchString(expr) {
+			return nil
+		}
+		names = append(names, expr[strings.LastIndex(expr, ".")+1:])
+	}
+	return names
+}
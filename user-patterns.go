@@ -3,8 +3,13 @@ package jsluice
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"regexp"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"gopkg.in/yaml.v3"
 )
 
 // A UserPattern represents a pattern that was provided by a
@@ -12,19 +17,26 @@ import (
 // directly, a SecretMatcher can be created directly instead
 // of creating a UserPattern
 type UserPattern struct {
-	Name     string   `json:"name"`
-	Key      string   `json:"key"`
-	Value    string   `json:"value"`
-	Severity Severity `json:"severity"`
+	Name     string   `json:"name" yaml:"name"`
+	Key      string   `json:"key" yaml:"key"`
+	Value    string   `json:"value" yaml:"value"`
+	Severity Severity `json:"severity" yaml:"severity"`
+
+	Object []*UserPattern `json:"object" yaml:"object"`
 
-	Object []*UserPattern `json:"object"`
+	// Query is a tree-sitter query to run instead of the default
+	// queries for strings, pairs, and objects. If Capture is set,
+	// only nodes with that capture name are tested against Value.
+	Query   string `json:"query" yaml:"query"`
+	Capture string `json:"capture" yaml:"capture"`
 
 	reKey   *regexp.Regexp
 	reValue *regexp.Regexp
 }
 
 // ParseRegex parses all of the user-provided regular expressions
-// for a pattern into Go *regexp.Regexp types
+// for a pattern into Go *regexp.Regexp types. Any user-provided
+// tree-sitter query is also checked for validity.
 func (u *UserPattern) ParseRegex() error {
 	if u.Value != "" {
 		re, err := regexp.Compile(u.Value)
@@ -44,7 +56,10 @@ func (u *UserPattern) ParseRegex() error {
 
 	if len(u.Object) > 0 {
 		for _, m := range u.Object {
-			m.ParseRegex()
+			err := m.ParseRegex()
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -52,8 +67,32 @@ func (u *UserPattern) ParseRegex() error {
 		u.Severity = SeverityInfo
 	}
 
+	if !u.Severity.Valid() {
+		return fmt.Errorf(
+			"invalid severity '%s' in user-defined matcher '%s'; must be one of 'info', 'low', 'medium', or 'high'",
+			u.Severity, u.Name,
+		)
+	}
+
+	if u.Capture != "" && u.Query == "" {
+		return fmt.Errorf("'capture' requires a 'query' in user-defined matcher '%s'", u.Name)
+	}
+
+	if u.Query != "" {
+		q, err := sitter.NewQuery([]byte(u.Query), javascript.GetLanguage())
+		if err != nil {
+			return fmt.Errorf("invalid query in user-defined matcher '%s': %w", u.Name, err)
+		}
+
+		if u.Capture != "" && !hasCapture(q, u.Capture) {
+			return fmt.Errorf("query in user-defined matcher '%s' has no capture named '%s'", u.Name, u.Capture)
+		}
+
+		return nil
+	}
+
 	if u.reValue == nil && u.reKey == nil && len(u.Object) == 0 {
-		return errors.New("'key', 'value', both, or 'object' must be supplied in user-defined matcher")
+		return errors.New("'key', 'value', both, 'object', or 'query' must be supplied in user-defined matcher")
 	}
 
 	return nil
@@ -80,6 +119,10 @@ func (u *UserPattern) MatchKey(in string) bool {
 // SecretMatcher returns a SecretMatcher based on the UserPattern,
 // for use with (*Analyzer).AddSecretMatcher()
 func (u *UserPattern) SecretMatcher() SecretMatcher {
+	if u.Query != "" {
+		return u.queryMatcher()
+	}
+
 	if len(u.Object) > 0 {
 		return u.objectMatcher()
 	}
@@ -91,25 +134,47 @@ func (u *UserPattern) SecretMatcher() SecretMatcher {
 	return u.stringMatcher()
 }
 
-// objectMatcher returns a SecretMatcher for matching against objects
-func (u *UserPattern) objectMatcher() SecretMatcher {
-	return SecretMatcher{"(object) @matches", func(n *Node) *Secret {
-		pairs := n.NamedChildren()
+// queryMatcher returns a SecretMatcher for matching against
+// the nodes captured by a user-provided query
+func (u *UserPattern) queryMatcher() SecretMatcher {
+	return SecretMatcher{Name: u.Name, Kind: u.Name, Severity: u.Severity, Query: u.Query, Fn: func(n *Node) *Secret {
+		if u.Capture != "" && n.CaptureName() != u.Capture {
+			return nil
+		}
 
-		matched := 0
+		in := n.Content()
+		if n.Type() == "string" {
+			in = n.RawString()
+		}
 
-		for _, pat := range u.Object {
-			matcher := pat.pairMatcher()
+		if !u.MatchValue(in) {
+			return nil
+		}
 
-			for _, pair := range pairs {
-				if matcher.Fn(pair) != nil {
-					matched++
-					break
-				}
-			}
+		secret := &Secret{
+			Kind:     u.Name,
+			Data:     map[string]string{"match": in},
+			Severity: u.Severity,
+		}
+
+		return secret
+	}}
+}
+
+// hasCapture returns true if the query contains a capture with the provided name
+func hasCapture(q *sitter.Query, name string) bool {
+	for i := uint32(0); i < q.CaptureCount(); i++ {
+		if q.CaptureNameForId(i) == name {
+			return true
 		}
+	}
+	return false
+}
 
-		if matched != len(u.Object) {
+// objectMatcher returns a SecretMatcher for matching against objects
+func (u *UserPattern) objectMatcher() SecretMatcher {
+	return SecretMatcher{Name: u.Name, Kind: u.Name, Severity: u.Severity, Query: "(object) @matches", Fn: func(n *Node) *Secret {
+		if !u.matchObject(n) {
 			return nil
 		}
 
@@ -119,13 +184,74 @@ func (u *UserPattern) objectMatcher() SecretMatcher {
 			Severity: u.Severity,
 		}
 
+		// Flattening the object to a map of strings would hide the
+		// nested values that were matched, so we keep them for
+		// patterns that have nested objects.
+		if u.hasNestedObject() {
+			secret.Data = n.AsMap()
+		}
+
 		return secret
 	}}
 }
 
+// matchObject returns true if every one of the pattern's object
+// patterns matches at least one of the pairs in the object Node
+func (u *UserPattern) matchObject(n *Node) bool {
+	if n.Type() != "object" {
+		return false
+	}
+
+	pairs := n.NamedChildren()
+
+	for _, pat := range u.Object {
+		matched := false
+
+		for _, pair := range pairs {
+			if pat.matchPair(pair) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchPair returns true if the pattern matches the pair Node. Patterns
+// that have their own object patterns match pairs whose value is an
+// object matching those patterns, so that nested keys can be required.
+// E.g. {auth: {token: "..."}}
+func (u *UserPattern) matchPair(n *Node) bool {
+	if len(u.Object) == 0 {
+		return u.pairMatcher().Fn(n) != nil
+	}
+
+	if n.Type() != "pair" || !u.MatchKey(n.ChildByFieldName("key").RawString()) {
+		return false
+	}
+
+	return u.matchObject(n.ChildByFieldName("value"))
+}
+
+// hasNestedObject returns true if any of the pattern's
+// object patterns have object patterns of their own
+func (u *UserPattern) hasNestedObject() bool {
+	for _, pat := range u.Object {
+		if len(pat.Object) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // pairMatcher returns a SecretMatcher for matching against key/value pairs
 func (u *UserPattern) pairMatcher() SecretMatcher {
-	return SecretMatcher{"(pair) @matches", func(n *Node) *Secret {
+	return SecretMatcher{Name: u.Name, Kind: u.Name, Severity: u.Severity, Query: "(pair) @matches", Fn: func(n *Node) *Secret {
 
 		key := n.ChildByFieldName("key")
 		if key == nil || !u.MatchKey(key.RawString()) {
@@ -164,7 +290,7 @@ func (u *UserPattern) pairMatcher() SecretMatcher {
 
 // stringMatcher returns a SecretMatcher for matching against string literals
 func (u *UserPattern) stringMatcher() SecretMatcher {
-	return SecretMatcher{"(string) @matches", func(n *Node) *Secret {
+	return SecretMatcher{Name: u.Name, Kind: u.Name, Severity: u.Severity, Query: "(string) @matches", Fn: func(n *Node) *Secret {
 		in := n.RawString()
 		if !u.MatchValue(in) {
 			return nil
@@ -213,17 +339,164 @@ func ParseUserPatterns(r io.Reader) (UserPatterns, error) {
 	out := make(UserPatterns, 0)
 
 	dec := json.NewDecoder(r)
+
+	// Typos in field names (e.g. 'pattern' instead of 'value') would
+	// otherwise result in patterns that silently never match anything
+	dec.DisallowUnknownFields()
+
 	err := dec.Decode(&out)
 	if err != nil {
 		return out, err
 	}
 
-	for _, p := range out {
-		err = p.ParseRegex()
+	return out, out.parseRegex()
+}
+
+// ParseUserPatternsYAML is like ParseUserPatterns, but accepts an io.Reader
+// pointing to a YAML user-pattern definition file instead. Regular expressions
+// in YAML don't need their backslashes to be escaped, which makes them
+// easier to read and write than their JSON equivalents.
+func ParseUserPatternsYAML(r io.Reader) (UserPatterns, error) {
+	out := make(UserPatterns, 0)
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	err := dec.Decode(&out)
+	if err != nil {
+		return out, err
+	}
+
+	return out, out.parseRegex()
+}
+
+// parseRegex calls ParseRegex for every pattern,
+// returning the first error that occurs
+func (u UserPatterns) parseRegex() error {
+	for _, p := range u {
+		err := p.ParseRegex()
 		if err != nil {
-			return out, err
+			return err
 		}
 	}
+	return nil
+}
+
+// ValidateUserPatterns accepts an io.Reader pointing to a JSON user-pattern
+// definition file and checks every pattern in it, returning all of the
+// problems that are found. ParseUserPatterns stops at the first problem,
+// which can be tedious when writing large pattern files. A nil slice is
+// returned if the file is valid.
+func ValidateUserPatterns(r io.Reader) []error {
+	var patterns UserPatterns
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(&patterns)
+	if err != nil {
+		return []error{err}
+	}
+
+	return patterns.validate()
+}
+
+// ValidateUserPatternsYAML is like ValidateUserPatterns, but accepts
+// an io.Reader pointing to a YAML user-pattern definition file
+func ValidateUserPatternsYAML(r io.Reader) []error {
+	var patterns UserPatterns
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	err := dec.Decode(&patterns)
+	if err != nil {
+		return []error{err}
+	}
+
+	return patterns.validate()
+}
+
+// validate checks every pattern, returning all of the problems found
+func (u UserPatterns) validate() []error {
+	var errs []error
+
+	for i, p := range u {
+		label := fmt.Sprintf("pattern %d", i)
+		if p.Name != "" {
+			label = fmt.Sprintf("pattern %d (%s)", i, p.Name)
+		} else {
+			errs = append(errs, fmt.Errorf("%s: 'name' must be supplied", label))
+		}
+
+		errs = append(errs, p.validate(label, false)...)
+	}
+
+	return errs
+}
+
+// validate checks a single pattern without modifying it. Nested patterns
+// (i.e. those in an 'object' list) only use 'key', 'value', and 'object',
+// so the other fields are not allowed for them.
+func (u *UserPattern) validate(label string, nested bool) []error {
+	var errs []error
+	fail := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", label, fmt.Sprintf(format, a...)))
+	}
+
+	if u.Value != "" {
+		if _, err := regexp.Compile(u.Value); err != nil {
+			fail("invalid 'value' regex: %s", err)
+		}
+	}
+
+	if u.Key != "" {
+		if _, err := regexp.Compile(u.Key); err != nil {
+			fail("invalid 'key' regex: %s", err)
+		}
+	}
+
+	if u.Severity != "" && !u.Severity.Valid() {
+		fail("invalid severity '%s'; must be one of 'info', 'low', 'medium', or 'high'", u.Severity)
+	}
+
+	if nested && (u.Query != "" || u.Capture != "") {
+		fail("'query' and 'capture' can't be used in an 'object' pattern")
+	}
+
+	switch {
+	case u.Query != "":
+		if u.Key != "" || len(u.Object) > 0 {
+			fail("'key' and 'object' can't be used with 'query'")
+		}
+
+		q, err := sitter.NewQuery([]byte(u.Query), javascript.GetLanguage())
+		if err != nil {
+			fail("invalid query: %s", err)
+			break
+		}
+
+		if u.Capture != "" && !hasCapture(q, u.Capture) {
+			fail("query has no capture named '%s'", u.Capture)
+		}
+
+	case u.Capture != "":
+		fail("'capture' requires a 'query'")
+
+	case len(u.Object) > 0:
+		// A value only makes sense for a nested pattern, where the
+		// value of the pair is an object rather than a string
+		if u.Value != "" {
+			fail("'value' can't be used with 'object'")
+		}
+
+	case u.Key == "" && u.Value == "":
+		fail("one of 'key', 'value', 'object', or 'query' must be supplied")
+	}
+
+	for i, p := range u.Object {
+		errs = append(errs, p.validate(fmt.Sprintf("%s object %d", label, i), true)...)
+	}
 
-	return out, nil
+	return errs
 }
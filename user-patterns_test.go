@@ -69,3 +69,271 @@ func TestParseUserPatternsBadJSON(t *testing.T) {
 		t.Error("want non-nil error for ParseUserPatterns(testData) with bad JSON; but have nil", err)
 	}
 }
+
+func TestParseUserPatternsBadSeverity(t *testing.T) {
+	testData := strings.NewReader(`[
+		{"name": "httpAuth", "value": "[a-z]+@[a-z]+", "severity": "critical"}
+	]`)
+
+	_, err := ParseUserPatterns(testData)
+
+	if err == nil {
+		t.Error("want non-nil error for ParseUserPatterns(testData) with bad severity; but have nil")
+	}
+}
+
+func TestParseUserPatternsUnknownField(t *testing.T) {
+	testData := strings.NewReader(`[
+		{"name": "httpAuth", "value": "[a-z]+@[a-z]+", "sevreity": "high"}
+	]`)
+
+	_, err := ParseUserPatterns(testData)
+
+	if err == nil {
+		t.Error("want non-nil error for ParseUserPatterns(testData) with unknown field; but have nil")
+	}
+}
+
+func TestParseUserPatternsYAML(t *testing.T) {
+	testData := strings.NewReader(`
+- name: httpAuth
+  value: /[a-z0-9_/\.:-]+@[a-z0-9-]+\.[a-z0-9.-]+
+  severity: low
+
+- name: firebaseConfig
+  severity: high
+  object:
+    - key: apiKey
+      value: ^AIza.+
+    - key: authDomain
+`)
+
+	patterns, err := ParseUserPatternsYAML(testData)
+
+	if err != nil {
+		t.Fatalf("want nil error for ParseUserPatternsYAML(testData); have %s", err)
+	}
+
+	if len(patterns) != 2 {
+		t.Fatalf("want 2 patterns from ParseUserPatternsYAML(testData); have %d", len(patterns))
+	}
+
+	if !patterns[0].MatchValue("//someuser:somepass@example.com") {
+		t.Errorf("want (%s).MatchValue() to match a URL with credentials", patterns[0].reValue)
+	}
+
+	if patterns[0].Severity != SeverityLow {
+		t.Errorf("want low severity for first pattern; have %s", patterns[0].Severity)
+	}
+
+	if len(patterns[1].Object) != 2 {
+		t.Errorf("want 2 object patterns for second pattern; have %d", len(patterns[1].Object))
+	}
+}
+
+func TestParseUserPatternsYAMLUnknownField(t *testing.T) {
+	testData := strings.NewReader(`
+- name: httpAuth
+  pattern: "[a-z]+@[a-z]+"
+`)
+
+	_, err := ParseUserPatternsYAML(testData)
+
+	if err == nil {
+		t.Error("want non-nil error for ParseUserPatternsYAML(testData) with unknown field; but have nil")
+	}
+}
+
+func TestUserPatternQuery(t *testing.T) {
+	testData := strings.NewReader(`[
+		{
+			"name": "authHeader",
+			"query": "(call_expression function: (member_expression property: (property_identifier) @prop) arguments: (arguments (string) @header (string) @value))",
+			"capture": "value",
+			"value": "^Bearer "
+		}
+	]`)
+
+	patterns, err := ParseUserPatterns(testData)
+	if err != nil {
+		t.Fatalf("want nil error for ParseUserPatterns(testData); have %s", err)
+	}
+
+	a := NewAnalyzer([]byte(`
+		xhr.setRequestHeader("Authorization", "Bearer abc123")
+		xhr.setRequestHeader("Accept", "application/json")
+	`))
+	a.AddSecretMatchers(patterns.SecretMatchers())
+
+	found := 0
+	for _, s := range a.GetSecrets() {
+		if s.Kind != "authHeader" {
+			continue
+		}
+		found++
+
+		data := s.Data.(map[string]string)
+		if data["match"] != "Bearer abc123" {
+			t.Errorf("want 'Bearer abc123' for match; have %s", data["match"])
+		}
+	}
+
+	if found != 1 {
+		t.Errorf("want exactly 1 authHeader secret; have %d", found)
+	}
+}
+
+func TestUserPatternQueryPredicates(t *testing.T) {
+	testData := strings.NewReader(`
+- name: tokenSetter
+  query: |
+    (call_expression
+      function: (member_expression property: (property_identifier) @prop)
+      arguments: (arguments (string) @value)
+      (#eq? @prop "setToken")
+      (#match? @value "^.tok_"))
+  capture: value
+  value: ^tok_[a-z0-9]+$
+`)
+
+	patterns, err := ParseUserPatternsYAML(testData)
+	if err != nil {
+		t.Fatalf("want nil error for ParseUserPatternsYAML(testData); have %s", err)
+	}
+
+	a := NewAnalyzer([]byte(`
+		client.setToken("tok_abc123")
+		client.setToken("sk_abc123")
+		client.setName("tok_notatoken")
+		client.setToken("tok_\x41BC")
+	`))
+	a.AddSecretMatchers(patterns.SecretMatchers())
+
+	matches := make([]string, 0)
+	for _, s := range a.GetSecrets() {
+		if s.Kind != "tokenSetter" {
+			continue
+		}
+		matches = append(matches, s.Data.(map[string]string)["match"])
+	}
+
+	// The predicates exclude setName and sk_abc123, and the value regex
+	// is tested against the decoded string, so tok_\x41BC is excluded too
+	if len(matches) != 1 || matches[0] != "tok_abc123" {
+		t.Errorf("want only tok_abc123 to match; have %v", matches)
+	}
+}
+
+func TestUserPatternBadQuery(t *testing.T) {
+	cases := []string{
+		`[{"name": "bad", "query": "(call_expression"}]`,
+		`[{"name": "bad", "query": "(string) @str", "capture": "nope"}]`,
+		`[{"name": "bad", "value": "foo", "capture": "str"}]`,
+	}
+
+	for _, c := range cases {
+		_, err := ParseUserPatterns(strings.NewReader(c))
+		if err == nil {
+			t.Errorf("want non-nil error for ParseUserPatterns(%s); have nil", c)
+		}
+	}
+}
+
+func TestUserPatternNestedObject(t *testing.T) {
+	testData := strings.NewReader(`[
+		{
+			"name": "nestedToken",
+			"severity": "medium",
+			"object": [
+				{"key": "^endpoint$"},
+				{"key": "^auth$", "object": [
+					{"key": "^token$", "value": "^tok_"}
+				]}
+			]
+		}
+	]`)
+
+	patterns, err := ParseUserPatterns(testData)
+	if err != nil {
+		t.Fatalf("want nil error for ParseUserPatterns(testData); have %s", err)
+	}
+
+	a := NewAnalyzer([]byte(`
+		var good = {endpoint: "/api", auth: {token: "tok_abc123"}}
+		var wrongValue = {endpoint: "/api", auth: {token: "abc123"}}
+		var notNested = {endpoint: "/api", token: "tok_abc123"}
+	`))
+	a.AddSecretMatchers(patterns.SecretMatchers())
+
+	found := 0
+	for _, s := range a.GetSecrets() {
+		if s.Kind != "nestedToken" {
+			continue
+		}
+		found++
+
+		data := s.Data.(map[string]any)
+		auth, ok := data["auth"].(map[string]any)
+		if !ok || auth["token"] != "tok_abc123" {
+			t.Errorf("want nested token 'tok_abc123' in data; have %v", data)
+		}
+	}
+
+	if found != 1 {
+		t.Errorf("want exactly 1 nestedToken secret; have %d", found)
+	}
+}
+
+func TestValidateUserPatterns(t *testing.T) {
+	testData := strings.NewReader(`[
+		{"name": "ok", "key": "^api", "value": "^[a-z]+$", "severity": "low"},
+		{"name": "badValue", "value": "(unclosed"},
+		{"name": "badSeverity", "value": "x", "severity": "critical"},
+		{"value": "noName"},
+		{"name": "empty"},
+		{"name": "objectAndValue", "value": "x", "object": [{"key": "(bad"}]},
+		{"name": "badQuery", "query": "(not_a_node) @m"},
+		{"name": "noCapture", "query": "(string) @m", "capture": "other"}
+	]`)
+
+	errs := ValidateUserPatterns(testData)
+
+	// objectAndValue has two problems: the value and the nested key
+	if len(errs) != 8 {
+		t.Errorf("want 8 errors from ValidateUserPatterns(testData); have %d", len(errs))
+		for _, err := range errs {
+			t.Log(err)
+		}
+	}
+
+	for _, err := range errs {
+		if strings.HasPrefix(err.Error(), "pattern 0 ") {
+			t.Errorf("want no errors for valid pattern; have %s", err)
+		}
+	}
+}
+
+func TestValidateUserPatternsValid(t *testing.T) {
+	testData := strings.NewReader(`
+- name: httpAuth
+  value: /[a-z0-9_/\.:-]+@[a-z0-9-]+\.[a-z0-9.-]+
+- name: firebaseConfig
+  severity: high
+  object:
+    - key: apiKey
+      value: ^AIza.+
+    - key: authDomain
+`)
+
+	errs := ValidateUserPatternsYAML(testData)
+	if len(errs) != 0 {
+		t.Errorf("want no errors from ValidateUserPatternsYAML(testData); have %v", errs)
+	}
+}
+
+func TestValidateUserPatternsBadJSON(t *testing.T) {
+	errs := ValidateUserPatterns(strings.NewReader(`[{"name": "x", "value": `))
+	if len(errs) != 1 {
+		t.Errorf("want 1 error from ValidateUserPatterns() with bad JSON; have %d", len(errs))
+	}
+}